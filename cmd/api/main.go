@@ -3,26 +3,32 @@ package main
 
 import (
 	"cashback-tracker/internal/auth"
+	"cashback-tracker/internal/bot"
 	"cashback-tracker/internal/config"
+	"cashback-tracker/internal/deadline"
 	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/events"
 	"cashback-tracker/internal/handler"
 	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/outbox"
+	"cashback-tracker/internal/reminder"
+	"cashback-tracker/internal/scheduler"
+	"cashback-tracker/internal/summary"
+	"cashback-tracker/internal/webhook"
 	"cashback-tracker/internal/storage/postgres"
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
+	"os/signal"
+	"syscall"
 	"time"
-	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/text/encoding/charmap"
 
 	"database/sql"	
 	"github.com/pressly/goose/v3"
@@ -64,6 +70,7 @@ func main() {
 	defer pool.Close()
 
 	store := postgres.NewStorage(pool)
+	bus := events.NewBus(eventHistorySize)
 	tokenService := auth.NewTokenService(cfg)
 
 	if err := runMigrations(context.Background(), cfg.DBConn); err != nil {
@@ -73,349 +80,143 @@ func main() {
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery())
+	router.Use(gin.Logger(), gin.Recovery(), middleware.Language())
 
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	handler.Register(router, store, tokenService, bus, idempotencyTTL)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go purgeLoop(ctx, "idempotency_keys", idempotencyTTL, store.PurgeIdempotencyKeys)
+
+	// изменения из outbox (и от API, и от бота) уходят в поток событий и в webhooks
+	hooks := webhook.NewDispatcher(store, 10*time.Second)
+	relay := outbox.NewRelay(store, time.Second)
+	relay.Subscribe("events", func(_ context.Context, e domain.Event) error {
+		bus.Publish(e)
+		return nil
 	})
+	relay.Subscribe("webhooks", hooks.Enqueue)
+	go relay.Run(ctx)
+	go hooks.Run(ctx)
+	go purgeLoop(ctx, "webhook_deliveries", webhookDeliveriesTTL, store.PurgeDeliveries)
+	go purgeLoop(ctx, "outbox_events", outboxTTL, store.PurgeOutboxEvents)
 
 	// Telegram webhook
+	var dispatcher *bot.Dispatcher
 	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
 	if botToken != "" {
-		bot, err := tgbotapi.NewBotAPI(botToken)
+		api, err := tgbotapi.NewBotAPI(botToken)
 		if err != nil {
 			slog.Error("Не удалось инициализировать Telegram бота", "error", err)
 			os.Exit(1)
 		}
 
-		webhookURL := os.Getenv("RENDER_EXTERNAL_URL") + "/telegram"
-		if _, err := bot.MakeRequest("setWebhook", map[string]string{"url": webhookURL}); err != nil {
+		if cfg.WebhookURL == "" {
+			slog.Error("Не задан адрес webhook: укажи TELEGRAM_WEBHOOK_URL или RENDER_EXTERNAL_URL")
+			os.Exit(1)
+		}
+		webhookURL := cfg.WebhookURL + cfg.WebhookPath
+		if _, err := api.MakeRequest("setWebhook", tgbotapi.Params{
+			"url":          webhookURL,
+			"secret_token": cfg.WebhookSecret,
+		}); err != nil {
 			slog.Error("Не удалось установить webhook", "error", err)
 			os.Exit(1)
 		}
 		slog.Info("Telegram webhook установлен", "url", webhookURL)
 
-		router.POST("/telegram", func(c *gin.Context) {
-			var update tgbotapi.Update
-			if err := c.ShouldBindJSON(&update); err != nil {
-				slog.Error("Ошибка парсинга обновления", "error", err)
-				c.Status(http.StatusBadRequest)
-				return
-			}
-			if update.Message == nil {
-				c.Status(http.StatusOK)
-				return
-			}
-
-			chatID := update.Message.Chat.ID
-			userID := int64(update.Message.From.ID)
-			text := strings.TrimSpace(update.Message.Text)
-			slog.Info("📥 Получено сообщение", "user_id", userID, "text", text)
-
-			var msgText string
-			var errHandle error
-
-			switch {
-			case text == "/start" || text == "/help":
-				msgText = "🏦 Кэшбэк-трекер\n\n" +
-					"Команды:\n" +
-					"/add — добавить банк: Сбер: Аптеки 5, Такси 10\n" +
-					"/month — показать кэшбэк за текущий месяц\n" +
-					"/search_bank Сбер — найти категории по банку\n" +
-					"/search_cat Аптеки — найти банки по категории\n" +
-					"/delete_bank Сбер — удалить банк\n" +
-					"/delete_cat Сбер Аптеки — удалить категорию"
-
-			case text == "/month":
-				msgText, errHandle = handleMonth(store, userID)
-
-			case strings.HasPrefix(text, "/search_bank "):
-				bankName := strings.TrimSpace(text[13:])
-				msgText, errHandle = handleSearchBank(store, userID, bankName)
-
-			case strings.HasPrefix(text, "/search_cat "):
-				catName := strings.TrimSpace(text[12:])
-				msgText, errHandle = handleSearchCategory(store, userID, catName)
-
-			case strings.HasPrefix(text, "/delete_bank "):
-				parts := strings.Split(text, " ")
-				if len(parts) < 2 {
-					msgText = "❌ Используй: /delete_bank Банк"
-				} else {
-					bankName := parts[1]
-					errHandle = handleDeleteBank(store, userID, bankName)
-					if errHandle == nil {
-						msgText = "✅ Банк удалён"
-					}
-				}
-
-			case strings.HasPrefix(text, "/delete_cat "):
-				parts := strings.Split(text, " ")
-				if len(parts) < 3 {
-					msgText = "❌ Используй: /delete_cat Банк Категория"
-				} else {
-					bankName := parts[1]
-					catName := strings.Join(parts[2:], " ")
-					errHandle = handleDeleteCategory(store, userID, bankName, catName)
-					if errHandle == nil {
-						msgText = "✅ Категория удалена"
-					}
-				}
-
-			case strings.HasPrefix(text, "/add "):
-				input := strings.TrimSpace(text[5:])
-				errHandle = saveFromMessage(store, userID, input)
-				if errHandle == nil {
-					msgText = "✅ Сохранено!"
-				}
-
-			default:
-				msgText = "Неизвестная команда. Напиши /help"
-			}
-
-			if errHandle != nil {
-				msgText = "❌ Ошибка: " + errHandle.Error()
-			}
-
-			// 🔥 ЕДИНСТВЕННАЯ ОТПРАВКА ОТВЕТА
-			msg := tgbotapi.NewMessage(chatID, msgText)
-			// msg.ParseMode = "Markdown"
-			if _, err := bot.Send(msg); err != nil {
-				slog.Error("Не удалось отправить ответ", "error", err)
-			}
-
-			c.Status(http.StatusOK)
-		})
-	}
+		b := bot.New(api, bot.NewSender(api, store), store, tgbotapi.ModeHTML)
+		dispatcher = bot.NewDispatcher(bot.Deduplicate(b, store), cfg.BotWorkers, cfg.BotQueueSize)
+		router.POST(cfg.WebhookPath, handler.NewTelegramHandler(dispatcher, cfg.WebhookSecret).Webhook)
 
-	// API
-	router.POST("/api/v1/login", func(c *gin.Context) {
-		var req struct{ UserID int64 `json:"user_id"` }
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id required"})
-			return
+		sched := scheduler.New(store, time.Minute)
+		sched.Handle(reminder.JobKind, b.RemindJob)
+		sched.Handle(deadline.JobKind, b.DeadlineJob(cfg.DeadlineAlertDays))
+		sched.Handle(summary.JobKind, b.DigestJob)
+		if err := store.EnsureJob(ctx, deadline.JobKind, 0, time.Now()); err != nil {
+			slog.Error("Не удалось завести задачу предупреждений о сроках", "error", err)
 		}
-		token, err := tokenService.GenerateToken(req.UserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "token generation failed"})
-			return
+		if err := store.EnsureJob(ctx, summary.JobKind, 0, summary.NextRunAt(time.Now())); err != nil {
+			slog.Error("Не удалось завести задачу рассылки итогов месяца", "error", err)
 		}
-		c.JSON(http.StatusOK, gin.H{"token": token})
-	})
+		go sched.Run(ctx)
 
-	authMiddleware := middleware.NewAuthMiddleware(tokenService)
-	v1 := router.Group("/api/v1")
-	v1.Use(authMiddleware.RequireAuth())
-	{
-		v1.POST("/month", cashbackHandler(store).SaveMonth)
-		v1.GET("/month", cashbackHandler(store).GetMonth)
-		v1.GET("/search/category", cashbackHandler(store).SearchByCategory)
-		v1.GET("/search/bank", cashbackHandler(store).SearchByBank)
-		v1.PUT("/month", cashbackHandler(store).SaveMonth)
-		v1.PATCH("/month", cashbackHandler(store).PatchMonth)
-		v1.DELETE("/month/bank", cashbackHandler(store).DeleteBankFromMonth)
-		v1.DELETE("/month/bank/category", cashbackHandler(store).DeleteCategoryFromBank)
+		// Telegram повторяет доставку не дольше суток, неделя — с запасом
+		go purgeLoop(ctx, "telegram_updates", 7*24*time.Hour, store.PurgeProcessedUpdates)
 	}
 
+	// соответствие документа маршрутам проверяет internal/handler/router_test.go
+	doc := handler.OpenAPI()
+	docs, err := handler.NewDocsHandler(doc)
+	if err != nil {
+		slog.Error("Не удалось собрать OpenAPI-документ", "error", err)
+		os.Exit(1)
+	}
+	router.GET("/openapi.json", docs.Spec)
+	router.GET("/docs", docs.UI)
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "10000"
 	}
-	slog.Info("🚀 Сервер запущен", "port", port)
-	if err := router.Run(":" + port); err != nil {
-		slog.Error("Сервер завершил работу с ошибкой", "error", err)
-	}
-}
-
-// --- Вспомогательные функции ---
-
-func cashbackHandler(store any) *handler.CashbackHandler {
-	return handler.NewCashbackHandler(store.(handler.CombinedStorage))
-}
-
-func saveFromMessage(store *postgres.Storage, userID int64, input string) error {
-	if !strings.Contains(input, ":") {
-		return fmt.Errorf("используй формат: Банк: Категория1 5, Категория2 10")
-	}
-
-	parts := strings.SplitN(input, ":", 2)
-	bankName := strings.TrimSpace(parts[0])
-	categoriesStr := strings.TrimSpace(parts[1])
-
-	if bankName == "" || categoriesStr == "" {
-		return fmt.Errorf("банк и категории не могут быть пустыми")
-	}
-
-	var categories []domain.CashbackCategory
-	for _, catPart := range strings.Split(categoriesStr, ",") {
-		catPart = strings.TrimSpace(catPart)
-		fields := strings.Fields(catPart)
-		if len(fields) < 2 {
-			return fmt.Errorf("категория должна содержать название и процент: %q", catPart)
-		}
-
-		percentStr := fields[len(fields)-1]
-		percent, err := strconv.ParseFloat(percentStr, 32)
-		if err != nil {
-			return fmt.Errorf("неверный процент: %q", percentStr)
-		}
-
-		catName := strings.Join(fields[:len(fields)-1], " ")
-		if catName == "" {
-			return fmt.Errorf("название категории не может быть пустым")
+	srv := &http.Server{Addr: ":" + port, Handler: router}
+	// Shutdown не ждёт открытые потоки событий: закрытие шины завершает их сразу
+	srv.RegisterOnShutdown(bus.Close)
+	go func() {
+		slog.Info("🚀 Сервер запущен", "port", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Сервер завершил работу с ошибкой", "error", err)
+			os.Exit(1)
 		}
+	}()
 
-		categories = append(categories, domain.CashbackCategory{
-			Category: domain.Category{Name: catName},
-			Percent:  float32(percent),
-		})
-	}
-
-	if len(categories) == 0 {
-		return fmt.Errorf("не найдено ни одной валидной категории")
-	}
+	<-ctx.Done()
+	slog.Info("Останавливаем сервер")
 
-	month := time.Now().Format("2006-01")
-	bankWithCat := []domain.BankWithCategories{{
-		Bank:       domain.Bank{Name: bankName},
-		Categories: categories,
-	}}
-
-	return store.PatchMonth(context.Background(), userID, month, bankWithCat)
-}
-
-func handleMonth(store *postgres.Storage, userID int64) (string, error) {
-	month := time.Now().Format("2006-01")
-	cashback, err := store.GetMonth(context.Background(), userID, month)
-	if err != nil {
-		return "", err
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Не удалось корректно остановить HTTP-сервер", "error", err)
 	}
-	if cashback == nil || len(cashback.Banks) == 0 {
-		return "📭 Нет данных за " + month, nil
-	}
-
-	var lines []string
-	lines = append(lines, fmt.Sprintf("🏦 Кэшбэк за %s", month))
-	for _, bwc := range cashback.Banks {
-		lines = append(lines, fmt.Sprintf("\n*%s*", bwc.Bank.Name))
-		for _, cc := range bwc.Categories {
-			lines = append(lines, fmt.Sprintf("- %s: %.1f%%", cc.Category.Name, cc.Percent))
+	// после остановки HTTP новых обновлений не будет — дорабатываем очередь
+	if dispatcher != nil {
+		if err := dispatcher.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Очередь обновлений не успела обработаться", "error", err)
 		}
 	}
-	return strings.Join(lines, "\n"), nil
 }
 
-func handleSearchBank(store *postgres.Storage, userID int64, bankName string) (string, error) {
-	if bankName == "" {
-		return "❌ Укажи название банка", nil
-	}
-	month := time.Now().Format("2006-01")
-	
-	// Получаем ВЕСЬ месяц
-	cashback, err := store.GetMonth(context.Background(), userID, month)
-	if err != nil {
-		return "", err
-	}
-	if cashback == nil || len(cashback.Banks) == 0 {
-		return fmt.Sprintf("📭 Нет данных за %s", month), nil
-	}
+// --- Вспомогательные функции ---
 
-	// Ищем нужный банк
-	var targetBank *domain.BankWithCategories
-	for _, bwc := range cashback.Banks {
-		if strings.EqualFold(bwc.Bank.Name, bankName) {
-			targetBank = &bwc
-			break
+// purgeLoop раз в час удаляет из name записи старше keep, пока не отменён ctx
+func purgeLoop(ctx context.Context, name string, keep time.Duration, purge func(context.Context, time.Time) (int64, error)) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
-	}
-
-	if targetBank == nil {
-		return fmt.Sprintf("📭 Нет кэшбэка по банку %s", bankName), nil
-	}
-
-	// Формируем ответ с процентами
-	var lines []string
-	lines = append(lines, fmt.Sprintf("🔍 Категории для %s", bankName))
-	for _, cc := range targetBank.Categories {
-		lines = append(lines, fmt.Sprintf("- %s: %.1f%%", cc.Category.Name, cc.Percent))
-	}
-	return strings.Join(lines, "\n"), nil
-}
-
-func handleSearchCategory(store *postgres.Storage, userID int64, categoryName string) (string, error) {
-	if categoryName == "" {
-		return "❌ Укажи название категории", nil
-	}
-	month := time.Now().Format("2006-01")
-	
-	cashback, err := store.GetMonth(context.Background(), userID, month)
-	if err != nil {
-		return "", err
-	}
-	if cashback == nil || len(cashback.Banks) == 0 {
-		return fmt.Sprintf("📭 Нет данных за %s", month), nil
-	}
-
-	var banksWithCategory []domain.BankWithCategories
-	for _, bwc := range cashback.Banks {
-		for _, cc := range bwc.Categories {
-			if strings.EqualFold(cc.Category.Name, categoryName) {
-				banksWithCategory = append(banksWithCategory, domain.BankWithCategories{
-					Bank: bwc.Bank,
-					Categories: []domain.CashbackCategory{cc}, // только нужная категория
-				})
-				break
-			}
+		n, err := purge(ctx, time.Now().Add(-keep))
+		if err != nil {
+			slog.Error("Не удалось очистить старые записи", "table", name, "error", err)
+			continue
+		}
+		if n > 0 {
+			slog.Info("Очищены старые записи", "table", name, "count", n)
 		}
 	}
-
-	if len(banksWithCategory) == 0 {
-		return fmt.Sprintf("📭 Нет кэшбэка по категории %s", categoryName), nil
-	}
-
-	var lines []string
-	lines = append(lines, fmt.Sprintf("🔍 Банки с кэшбэком по %s", categoryName))
-	for _, bwc := range banksWithCategory {
-		cc := bwc.Categories[0]
-		lines = append(lines, fmt.Sprintf("- %s: %.1f%%", bwc.Bank.Name, cc.Percent))
-	}
-	return strings.Join(lines, "\n"), nil
-}
-
-func handleDeleteBank(store *postgres.Storage, userID int64, bankName string) error {
-	if bankName == "" {
-		return fmt.Errorf("укажи название банка")
-	}
-	month := time.Now().Format("2006-01")
-	return store.DeleteBankFromMonth(context.Background(), userID, month, bankName)
 }
 
-func handleDeleteCategory(store *postgres.Storage, userID int64, bankName, categoryName string) error {
-	if bankName == "" || categoryName == "" {
-		return fmt.Errorf("укажи банк и категорию")
-	}
-	month := time.Now().Format("2006-01")
-	log.Printf("🗑️ Удаляем категорию: bank='%s', category='%s'", bankName, categoryName)
+// eventHistorySize — сколько последних событий хранится для докачки по Last-Event-ID
+const eventHistorySize = 1000
 
-	return store.DeleteCategoryFromBank(context.Background(), userID, month, bankName, categoryName)
-}
+// idempotencyTTL — сколько хранится ответ на запрос с Idempotency-Key
+const idempotencyTTL = 24 * time.Hour
 
+// webhookDeliveriesTTL — сколько хранится журнал завершённых доставок webhook
+const webhookDeliveriesTTL = 30 * 24 * time.Hour
 
-func fixEncoding(s string) string {
-	// Проверим, является ли строка валидной UTF-8
-	if utf8.ValidString(s) {
-		return s
-	}
-
-	// Пробуем перекодировать из windows-1251
-	decoder := charmap.Windows1251.NewDecoder()
-	fixed, err := decoder.String(s)
-	if err == nil && utf8.ValidString(fixed) {
-		return fixed
-	}
-
-	// Если не получилось — заменяем невалидные символы
-	return strings.ToValidUTF8(s, "")
-}
-
-// ... остальные функции (saveFromMessage, handleMonth и т.д.) — без изменений ...
+// outboxTTL — сколько хранятся опубликованные события outbox
+const outboxTTL = 7 * 24 * time.Hour
@@ -0,0 +1,114 @@
+// internal/batch/batch.go
+package batch
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxOperations — сколько операций можно прислать в одном пакете
+const MaxOperations = 100
+
+// Kind — вид операции пакета; каждая соответствует одному методу записи хранилища
+type Kind string
+
+const (
+	SaveMonth      Kind = "save_month"      // заменить месяц целиком
+	PatchMonth     Kind = "patch_month"     // дописать банки и категории
+	UpdateBank     Kind = "update_bank"     // заменить категории банка
+	DeleteBank     Kind = "delete_bank"     // убрать банк из месяца
+	DeleteCategory Kind = "delete_category" // убрать категорию у банка
+	DeleteMonth    Kind = "delete_month"    // удалить месяц
+)
+
+// Operation — одна операция пакета. Какие поля нужны, зависит от Kind.
+// Version, если задан, проверяется как If-Match для месяца операции.
+type Operation struct {
+	Kind       Kind
+	Month      string
+	Bank       string
+	Category   string
+	Banks      []domain.BankWithCategories
+	Categories []domain.CashbackCategory
+	Version    *int
+}
+
+// Result — итог одной операции
+type Result struct {
+	Index  int    `json:"index"`
+	Op     Kind   `json:"op"`
+	Month  string `json:"month"`
+	Status string `json:"status"`
+}
+
+// OpError — операция, на которой пакет остановился; всё сделанное до неё откачено
+type OpError struct {
+	Index int
+	Op    Kind
+	Err   error
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// errDryRun откатывает транзакцию пробного прогона
+var errDryRun = errors.New("dry run")
+
+// Run выполняет операции по порядку в одной транзакции. При ошибке откатывается весь
+// пакет и возвращается *OpError. dryRun выполняет всё и откатывает, чтобы проверить пакет.
+func Run(ctx context.Context, store storage.TxStorage, userID int64, ops []Operation, dryRun bool) ([]Result, error) {
+	var results []Result
+	err := store.WithinTx(ctx, func(tx storage.CashbackStorage) error {
+		results = make([]Result, 0, len(ops))
+		for i, op := range ops {
+			opCtx := ctx
+			if op.Version != nil {
+				opCtx = storage.WithVersion(ctx, *op.Version)
+			}
+			if err := apply(opCtx, tx, userID, op); err != nil {
+				return &OpError{Index: i, Op: op.Kind, Err: err}
+			}
+			results = append(results, Result{Index: i, Op: op.Kind, Month: op.Month, Status: "ok"})
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func apply(ctx context.Context, tx storage.CashbackStorage, userID int64, op Operation) error {
+	switch op.Kind {
+	case SaveMonth:
+		_, err := tx.SaveMonth(ctx, userID, op.Month, op.Banks)
+		return err
+	case PatchMonth:
+		_, err := tx.PatchMonth(ctx, userID, op.Month, op.Banks)
+		return err
+	case UpdateBank:
+		_, err := tx.UpdateBankCategories(ctx, userID, op.Month, op.Bank, op.Categories)
+		return err
+	case DeleteBank:
+		return tx.DeleteBankFromMonth(ctx, userID, op.Month, op.Bank)
+	case DeleteCategory:
+		return tx.DeleteCategoryFromBank(ctx, userID, op.Month, op.Bank, op.Category)
+	case DeleteMonth:
+		return tx.DeleteMonth(ctx, userID, op.Month)
+	}
+	return storage.Invalid("storage.unknown_operation", op.Kind)
+}
@@ -0,0 +1,197 @@
+// internal/bot/bot.go
+package bot
+
+import (
+	"cashback-tracker/internal/deadline"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/reminder"
+	"cashback-tracker/internal/storage"
+	"cashback-tracker/internal/summary"
+	"context"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type Storage interface {
+	storage.CashbackStorage
+	storage.SettingsStorage
+	reminder.Store
+	deadline.Store
+	storage.PurchaseStorage
+	storage.NameSearchStorage
+}
+
+// Bot — общая логика команд для long polling (cmd/bot) и webhook (cmd/api)
+type Bot struct {
+	api       *tgbotapi.BotAPI
+	sender    *Sender
+	store     Storage
+	reminders *reminder.Service
+	deadlines *deadline.Service
+	summaries *summary.Service
+	render    Renderer
+	pending   *pendingWrites
+}
+
+// New создаёт бота; parseMode — tgbotapi.ModeHTML, tgbotapi.ModeMarkdownV2 или "" для обычного текста.
+// Все ответы уходят через sender.
+func New(api *tgbotapi.BotAPI, sender *Sender, store Storage, parseMode string) *Bot {
+	return &Bot{
+		api:       api,
+		sender:    sender,
+		store:     store,
+		reminders: reminder.NewService(store),
+		deadlines: deadline.NewService(store),
+		summaries: summary.NewService(store),
+		render:    NewRenderer(parseMode),
+		pending:   newPendingWrites(),
+	}
+}
+
+// t берёт шаблон из каталога и подставляет аргументы с экранированием
+func (b *Bot) t(lang i18n.Lang, key string, args ...any) Markup {
+	return b.render.Format(i18n.T(lang, key), args...)
+}
+
+// send отправляет ответ, при необходимости разбивая его на несколько сообщений.
+// После ошибки остальные части не шлём: отправитель уже залогировал её и сохранил сообщение.
+func (b *Bot) send(ctx context.Context, chatID int64, msg Message) {
+	for _, chunk := range Split(msg, MaxMessageLength) {
+		out := tgbotapi.NewMessage(chatID, chunk)
+		out.ParseMode = b.render.mode
+		if _, err := b.sender.Send(ctx, out); err != nil {
+			return
+		}
+	}
+}
+
+// HandleUpdate обрабатывает одно обновление и отправляет ответ пользователю
+func (b *Bot) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
+	if update.CallbackQuery != nil && update.CallbackQuery.From != nil {
+		b.handleCallback(ctx, update.CallbackQuery)
+		return
+	}
+	if update.Message == nil || update.Message.From == nil {
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	userID := int64(update.Message.From.ID)
+	lang := b.language(ctx, userID, update.Message.From.LanguageCode)
+
+	if update.Message.Document != nil {
+		reply, err := b.handleImport(ctx, lang, userID, update.Message.Document, update.Message.Caption)
+		if err != nil {
+			reply = Message{b.t(lang, "bot.error", err)}
+		}
+		b.send(ctx, chatID, reply)
+		return
+	}
+
+	text := strings.TrimSpace(fixEncoding(update.Message.Text))
+	slog.Info("📥 Получено сообщение", "user_id", userID, "text", text)
+
+	var reply Message
+	var err error
+
+	switch {
+	case text == "/start" || text == "/help":
+		reply = Message{b.t(lang, "bot.help")}
+
+	case text == "/month" || strings.HasPrefix(text, "/month "):
+		asTable := strings.TrimSpace(strings.TrimPrefix(text, "/month")) == "table"
+		reply, err = b.handleMonth(ctx, lang, userID, asTable)
+
+	case strings.HasPrefix(text, "/search_bank "):
+		bankName := strings.TrimSpace(strings.TrimPrefix(text, "/search_bank "))
+		reply, err = b.handleSearchBank(ctx, lang, userID, bankName)
+
+	case strings.HasPrefix(text, "/search_cat "):
+		catName := strings.TrimSpace(strings.TrimPrefix(text, "/search_cat "))
+		reply, err = b.handleSearchCategory(ctx, lang, userID, catName)
+
+	case text == "/history_cat" || strings.HasPrefix(text, "/history_cat "):
+		reply, err = b.handleHistoryCategory(ctx, lang, userID, strings.TrimPrefix(text, "/history_cat"))
+
+	case text == "/compare" || strings.HasPrefix(text, "/compare "):
+		reply, err = b.handleCompare(ctx, lang, userID, strings.TrimPrefix(text, "/compare"))
+
+	case strings.HasPrefix(text, "/delete_bank "):
+		parts := strings.Split(text, " ")
+		if len(parts) < 2 {
+			reply = Message{b.t(lang, "bot.delete_bank.usage")}
+		} else {
+			err = b.handleDeleteBank(ctx, lang, userID, parts[1])
+			if err == nil {
+				reply = Message{b.t(lang, "bot.delete_bank.done")}
+			}
+		}
+
+	case strings.HasPrefix(text, "/delete_cat "):
+		parts := strings.Split(text, " ")
+		if len(parts) < 3 {
+			reply = Message{b.t(lang, "bot.delete_cat.usage")}
+		} else {
+			catName := strings.Join(parts[2:], " ")
+			err = b.handleDeleteCategory(ctx, lang, userID, parts[1], catName)
+			if err == nil {
+				reply = Message{b.t(lang, "bot.delete_cat.done")}
+			}
+		}
+
+	case strings.HasPrefix(text, "/add"):
+		if len(text) <= 4 {
+			reply = Message{b.t(lang, "bot.add.usage")}
+		} else {
+			var confirm bool
+			reply, confirm, err = b.handleAdd(ctx, lang, userID, strings.TrimSpace(text[4:]))
+			if err == nil && confirm {
+				b.sendConfirm(ctx, chatID, lang, reply)
+				return
+			}
+		}
+
+	case text == "/export" || strings.HasPrefix(text, "/export "):
+		var file tgbotapi.FileBytes
+		file, err = b.handleExport(ctx, lang, userID, strings.TrimPrefix(text, "/export"))
+		if err == nil {
+			b.sender.Send(ctx, tgbotapi.NewDocument(chatID, file))
+			return
+		}
+
+	case text == "/lang" || strings.HasPrefix(text, "/lang "):
+		reply, err = b.handleLang(ctx, userID, strings.TrimPrefix(text, "/lang"))
+
+	case text == "/summary" || strings.HasPrefix(text, "/summary "):
+		reply, err = b.handleSummary(ctx, lang, userID, strings.TrimPrefix(text, "/summary"))
+
+	case strings.HasPrefix(text, "/spent"):
+		reply, err = b.handleSpent(ctx, lang, userID, strings.TrimPrefix(text, "/spent"))
+
+	case text == "/remind" || strings.HasPrefix(text, "/remind "):
+		reply, err = b.handleRemind(ctx, lang, userID, strings.TrimPrefix(text, "/remind"))
+
+	default:
+		reply = Message{b.t(lang, "bot.unknown_command")}
+	}
+
+	if err != nil {
+		reply = Message{b.t(lang, "bot.error", err)}
+	}
+
+	b.send(ctx, chatID, reply)
+}
+
+// language выбирает язык ответа: сохранённый через /lang, иначе language_code из Telegram
+func (b *Bot) language(ctx context.Context, userID int64, telegramCode string) i18n.Lang {
+	saved, err := b.store.GetLanguage(ctx, userID)
+	if err != nil {
+		slog.Error("Не удалось получить язык пользователя", "error", err, "user_id", userID)
+	}
+	if lang, ok := i18n.Parse(saved); ok {
+		return lang
+	}
+	return i18n.FromTelegram(telegramCode)
+}
@@ -0,0 +1,436 @@
+// internal/bot/commands.go
+package bot
+
+import (
+	"bytes"
+	"cashback-tracker/internal/diff"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/export"
+	"cashback-tracker/internal/fuzzy"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// maxImportSize — ограничение на размер загружаемого файла
+const maxImportSize = 5 << 20
+
+// parseAddInput разбирает "Банк: Категория1 5, Категория2 10"
+func parseAddInput(lang i18n.Lang, input string) ([]domain.BankWithCategories, error) {
+	if !strings.Contains(input, ":") {
+		return nil, errors.New(i18n.T(lang, "bot.add.format"))
+	}
+
+	parts := strings.SplitN(input, ":", 2)
+	bankName := strings.TrimSpace(parts[0])
+	categoriesStr := strings.TrimSpace(parts[1])
+
+	if bankName == "" || categoriesStr == "" {
+		return nil, errors.New(i18n.T(lang, "bot.add.empty"))
+	}
+
+	var categories []domain.CashbackCategory
+	for _, catPart := range strings.Split(categoriesStr, ",") {
+		catPart = strings.TrimSpace(catPart)
+		fields := strings.Fields(catPart)
+		if len(fields) < 2 {
+			return nil, errors.New(i18n.T(lang, "bot.add.need_percent", catPart))
+		}
+
+		percentStr := fields[len(fields)-1]
+		percent, err := strconv.ParseFloat(percentStr, 32)
+		if err != nil {
+			return nil, errors.New(i18n.T(lang, "bot.add.bad_percent", percentStr))
+		}
+
+		catName := strings.Join(fields[:len(fields)-1], " ")
+		if catName == "" {
+			return nil, errors.New(i18n.T(lang, "bot.add.empty_category"))
+		}
+
+		categories = append(categories, domain.CashbackCategory{
+			Category: domain.Category{Name: catName},
+			Percent:  float32(percent),
+		})
+	}
+
+	if len(categories) == 0 {
+		return nil, errors.New(i18n.T(lang, "bot.add.no_categories"))
+	}
+
+	return []domain.BankWithCategories{{
+		Bank:       domain.Bank{Name: bankName},
+		Categories: categories,
+	}}, nil
+}
+
+func (b *Bot) handleMonth(ctx context.Context, lang i18n.Lang, userID int64, asTable bool) (Message, error) {
+	month := time.Now().Format("2006-01")
+	cashback, err := b.store.GetMonth(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	if cashback == nil || len(cashback.Banks) == 0 {
+		return append(Message{b.t(lang, "bot.month.empty", month)}, b.deadlineLines(ctx, lang, userID)...), nil
+	}
+
+	banks := cashback.Banks
+	sort.Slice(banks, func(i, j int) bool { return banks[i].Bank.Name < banks[j].Bank.Name })
+
+	msg := Message{b.t(lang, "bot.month.title", month)}
+	if asTable {
+		var rows [][]string
+		for _, bwc := range banks {
+			for i, cc := range bwc.Categories {
+				bank := bwc.Bank.Name
+				if i > 0 {
+					bank = ""
+				}
+				rows = append(rows, []string{bank, cc.Category.Name, fmt.Sprintf("%.1f%%", cc.Percent)})
+			}
+		}
+		header := []string{i18n.T(lang, "bot.table.bank"), i18n.T(lang, "bot.table.category"), "%"}
+		msg = append(msg, b.render.Table(header, rows)...)
+		return append(msg, b.deadlineLines(ctx, lang, userID)...), nil
+	}
+
+	for _, bwc := range banks {
+		msg = append(msg, b.render.Format("\n*%s*", bwc.Bank.Name))
+		for _, cc := range bwc.Categories {
+			msg = append(msg, b.render.Format("- %s: %.1f%%", cc.Category.Name, cc.Percent))
+		}
+	}
+	return append(msg, b.deadlineLines(ctx, lang, userID)...), nil
+}
+
+func (b *Bot) handleSearchBank(ctx context.Context, lang i18n.Lang, userID int64, bankName string) (Message, error) {
+	if bankName == "" {
+		return Message{b.t(lang, "bot.search_bank.need_name")}, nil
+	}
+	month := time.Now().Format("2006-01")
+
+	// Получаем ВЕСЬ месяц
+	cashback, err := b.store.GetMonth(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	if cashback == nil || len(cashback.Banks) == 0 {
+		return Message{b.t(lang, "bot.month.empty", month)}, nil
+	}
+
+	// Ищем нужный банк: «сбер» находит «Сбербанк», опечатка уходит в подсказки
+	names := make([]string, 0, len(cashback.Banks))
+	for _, bwc := range cashback.Banks {
+		names = append(names, bwc.Bank.Name)
+	}
+	name, ok := fuzzy.Best(bankName, names)
+	if !ok {
+		notFound := Message{b.t(lang, "bot.search_bank.not_found", bankName)}
+		return b.didYouMean(ctx, lang, userID, domain.NameBank, bankName, "/search_bank", notFound), nil
+	}
+	var targetBank domain.BankWithCategories
+	for _, bwc := range cashback.Banks {
+		if bwc.Bank.Name == name {
+			targetBank = bwc
+			break
+		}
+	}
+
+	// Формируем ответ с процентами
+	msg := Message{b.t(lang, "bot.search_bank.title", name)}
+	for _, cc := range targetBank.Categories {
+		msg = append(msg, b.render.Format("- %s: %.1f%%", cc.Category.Name, cc.Percent))
+	}
+	return msg, nil
+}
+
+func (b *Bot) handleSearchCategory(ctx context.Context, lang i18n.Lang, userID int64, categoryName string) (Message, error) {
+	if categoryName == "" {
+		return Message{b.t(lang, "bot.search_cat.need_name")}, nil
+	}
+	month := time.Now().Format("2006-01")
+
+	cashback, err := b.store.GetMonth(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if cashback != nil {
+		for _, bwc := range cashback.Banks {
+			for _, cc := range bwc.Categories {
+				names = append(names, cc.Category.Name)
+			}
+		}
+	}
+	name, ok := fuzzy.Best(categoryName, names)
+	if !ok {
+		notFound := Message{b.t(lang, "bot.search_cat.not_found", categoryName)}
+		return b.didYouMean(ctx, lang, userID, domain.NameCategory, categoryName, "/search_cat", notFound), nil
+	}
+
+	pivot, err := b.store.GetMonthByCategory(ctx, userID, month, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(pivot) == 0 {
+		return Message{b.t(lang, "bot.search_cat.not_found", categoryName)}, nil
+	}
+	msg := Message{b.t(lang, "bot.search_cat.title", name)}
+	for _, bp := range pivot[0].Banks {
+		msg = append(msg, b.render.Format("- %s: %.1f%%", bp.Bank, bp.Percent))
+	}
+	return msg, nil
+}
+
+func (b *Bot) handleDeleteBank(ctx context.Context, lang i18n.Lang, userID int64, bankName string) error {
+	if bankName == "" {
+		return errors.New(i18n.T(lang, "bot.delete_bank.need_name"))
+	}
+	month := time.Now().Format("2006-01")
+	err := b.store.DeleteBankFromMonth(ctx, userID, month, bankName)
+	if errors.Is(err, storage.ErrNotFound) {
+		return errors.New(i18n.T(lang, "bot.delete_bank.not_found", bankName))
+	}
+	return err
+}
+
+func (b *Bot) handleDeleteCategory(ctx context.Context, lang i18n.Lang, userID int64, bankName, categoryName string) error {
+	if bankName == "" || categoryName == "" {
+		return errors.New(i18n.T(lang, "bot.delete_cat.need_args"))
+	}
+	month := time.Now().Format("2006-01")
+	slog.Info("🗑️ Удаляем категорию", "bank", bankName, "category", categoryName)
+
+	err := b.store.DeleteCategoryFromBank(ctx, userID, month, bankName, categoryName)
+	if errors.Is(err, storage.ErrNotFound) {
+		return errors.New(i18n.T(lang, "bot.delete_cat.not_found", bankName, categoryName))
+	}
+	return err
+}
+
+func (b *Bot) handleExport(ctx context.Context, lang i18n.Lang, userID int64, args string) (tgbotapi.FileBytes, error) {
+	now := time.Now()
+	from := now.Format("2006") + "-01"
+	to := now.Format("2006-01")
+	format := export.FormatCSV
+
+	var months []string
+	for _, arg := range strings.Fields(args) {
+		if _, err := time.Parse("2006-01", arg); err == nil {
+			months = append(months, arg)
+			continue
+		}
+		f, err := export.ParseFormat(arg)
+		if err != nil {
+			return tgbotapi.FileBytes{}, errors.New(i18n.T(lang, "bot.export.usage"))
+		}
+		format = f
+	}
+	switch len(months) {
+	case 0:
+	case 1:
+		from, to = months[0], months[0]
+	case 2:
+		from, to = months[0], months[1]
+	default:
+		return tgbotapi.FileBytes{}, errors.New(i18n.T(lang, "bot.export.too_many"))
+	}
+	if from > to {
+		return tgbotapi.FileBytes{}, errors.New(i18n.T(lang, "bot.export.bad_range"))
+	}
+
+	rows, err := b.store.GetRange(ctx, userID, from, to)
+	if err != nil {
+		return tgbotapi.FileBytes{}, err
+	}
+	if len(rows) == 0 {
+		return tgbotapi.FileBytes{}, errors.New(i18n.T(lang, "bot.export.no_data", from, to))
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, rows); err != nil {
+		return tgbotapi.FileBytes{}, err
+	}
+	return tgbotapi.FileBytes{Name: format.FileName(from, to), Bytes: buf.Bytes()}, nil
+}
+
+func (b *Bot) handleImport(ctx context.Context, lang i18n.Lang, userID int64, doc *tgbotapi.Document, caption string) (Message, error) {
+	if doc.FileSize > maxImportSize {
+		return nil, errors.New(i18n.T(lang, "bot.import.too_big"))
+	}
+	format, err := export.ParseFormat(strings.TrimPrefix(filepath.Ext(doc.FileName), "."))
+	if err != nil || format == export.FormatXLSX {
+		return nil, errors.New(i18n.T(lang, "bot.import.unsupported"))
+	}
+
+	// Подпись "/import" применяет изменения, "/import replace" — перезаписывает месяцы целиком
+	dryRun := true
+	mode := export.ModeMerge
+	for _, f := range strings.Fields(caption) {
+		switch strings.ToLower(f) {
+		case "/import":
+			dryRun = false
+		case "replace":
+			mode = export.ModeReplace
+		}
+	}
+
+	fileURL, err := b.api.GetFileDirectURL(doc.FileID)
+	if err != nil {
+		slog.Error("Не удалось получить ссылку на файл импорта", "error", b.redactToken(err), "user_id", userID)
+		return nil, errors.New(i18n.T(lang, "bot.import.fetch_failed"))
+	}
+	body, err := b.download(ctx, fileURL)
+	if err != nil {
+		slog.Error("Не удалось скачать файл импорта", "error", b.redactToken(err), "user_id", userID)
+		return nil, errors.New(i18n.T(lang, "bot.import.download_failed"))
+	}
+	defer body.Close()
+
+	rows, rowErrs, err := export.Read(io.LimitReader(body, maxImportSize), format)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := export.Import(ctx, b.store, userID, rows, rowErrs, mode, dryRun)
+	if err != nil && !errors.Is(err, export.ErrInvalidRows) {
+		return nil, err
+	}
+	return b.formatImportResult(lang, result), nil
+}
+
+// downloadClient скачивает файлы из Telegram; без таймаута зависший ответ держал бы воркер
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+// download скачивает файл по ссылке Telegram. Ссылка содержит токен бота, поэтому
+// ошибки отсюда нельзя показывать пользователю — только писать в лог через redactToken.
+func (b *Bot) download(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("telegram file server responded %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// redactToken убирает токен бота из текста ошибки (url.Error содержит ссылку на файл)
+func (b *Bot) redactToken(err error) string {
+	if b.api.Token == "" {
+		return err.Error()
+	}
+	return strings.ReplaceAll(err.Error(), b.api.Token, "<redacted>")
+}
+
+func (b *Bot) formatImportResult(lang i18n.Lang, result *export.ImportResult) Message {
+	const maxLines = 30
+	var msg Message
+	result.Localize(lang)
+
+	if len(result.Errors) > 0 {
+		msg = append(msg, b.t(lang, "bot.import.invalid", result.Rows))
+		for i, e := range result.Errors {
+			if i == maxLines {
+				msg = append(msg, b.t(lang, "bot.import.more", len(result.Errors)-maxLines))
+				break
+			}
+			if e.Field != "" {
+				msg = append(msg, b.t(lang, "bot.import.row_field_error", e.Row, e.Field, e.Message))
+			} else {
+				msg = append(msg, b.t(lang, "bot.import.row_error", e.Row, e.Message))
+			}
+		}
+		return msg
+	}
+
+	if result.DryRun {
+		msg = append(msg, b.t(lang, "bot.import.dry_title", string(result.Mode), result.Rows))
+	} else {
+		msg = append(msg, b.t(lang, "bot.import.title", string(result.Mode), result.Rows))
+	}
+
+	shown := 0
+	for _, m := range result.Months {
+		var status Markup
+		switch {
+		case m.Error != "":
+			status = b.render.Format(" ❌ %s", m.Error)
+		case m.Applied:
+			status = " ✅"
+		case len(m.Changes) == 0:
+			status = b.t(lang, "bot.import.unchanged")
+		}
+		msg = append(msg, b.render.Format("\n*%s*%s", m.Month, status))
+		for _, ch := range m.Changes {
+			if shown == maxLines {
+				break
+			}
+			shown++
+			switch ch.Action {
+			case diff.ActionAdd:
+				msg = append(msg, b.render.Format("+ %s / %s: %.1f%%", ch.Bank, ch.Category, *ch.NewPercent))
+			case diff.ActionUpdate:
+				msg = append(msg, b.render.Format("~ %s / %s: %.1f%% → %.1f%%", ch.Bank, ch.Category, *ch.OldPercent, *ch.NewPercent))
+			case diff.ActionRemove:
+				msg = append(msg, b.render.Format("− %s / %s: %.1f%%", ch.Bank, ch.Category, *ch.OldPercent))
+			}
+		}
+	}
+	if shown == maxLines {
+		msg = append(msg, "…")
+	}
+
+	if result.DryRun {
+		msg = append(msg, "\n"+b.t(lang, "bot.import.hint"))
+	}
+	return msg
+}
+
+// handleLang сохраняет выбранный язык; ответ сразу на новом языке
+func (b *Bot) handleLang(ctx context.Context, userID int64, args string) (Message, error) {
+	lang, ok := i18n.Parse(args)
+	if !ok {
+		return Message{b.t(i18n.Default, "bot.lang.usage"), b.t(i18n.EN, "bot.lang.usage")}, nil
+	}
+	if err := b.store.SetLanguage(ctx, userID, string(lang)); err != nil {
+		return nil, err
+	}
+	return Message{b.t(lang, "bot.lang.done")}, nil
+}
+
+func fixEncoding(s string) string {
+	// Проверим, является ли строка валидной UTF-8
+	if utf8.ValidString(s) {
+		return s
+	}
+
+	// Пробуем перекодировать из windows-1251
+	decoder := charmap.Windows1251.NewDecoder()
+	fixed, err := decoder.String(s)
+	if err == nil && utf8.ValidString(fixed) {
+		return fixed
+	}
+
+	// Если не получилось — заменяем невалидные символы
+	return strings.ToValidUTF8(s, "")
+}
@@ -0,0 +1,66 @@
+// internal/bot/compare.go
+package bot
+
+import (
+	"cashback-tracker/internal/diff"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"context"
+	"strings"
+	"time"
+)
+
+// handleCompare: "[было стало]" — по умолчанию прошлый месяц против текущего
+func (b *Bot) handleCompare(ctx context.Context, lang i18n.Lang, userID int64, args string) (Message, error) {
+	fields := strings.Fields(args)
+	var from, to string
+	switch len(fields) {
+	case 0:
+		now := time.Now()
+		from, to = now.AddDate(0, -1, 0).Format("2006-01"), now.Format("2006-01")
+	case 2:
+		from, to = fields[0], fields[1]
+	default:
+		return Message{b.t(lang, "bot.compare.usage")}, nil
+	}
+	_, errFrom := time.Parse("2006-01", from)
+	_, errTo := time.Parse("2006-01", to)
+	if errFrom != nil || errTo != nil {
+		return Message{b.t(lang, "bot.compare.usage")}, nil
+	}
+
+	months, err := b.store.GetMonths(ctx, userID, []string{from, to})
+	if err != nil {
+		return nil, err
+	}
+	var oldBanks, newBanks []domain.BankWithCategories
+	for _, m := range months {
+		if m.Month == from {
+			oldBanks = m.Banks
+		}
+		if m.Month == to {
+			newBanks = m.Banks
+		}
+	}
+	return b.formatMonthDiff(lang, diff.Months(from, oldBanks, to, newBanks)), nil
+}
+
+func (b *Bot) formatMonthDiff(lang i18n.Lang, d diff.MonthDiff) Message {
+	msg := Message{b.t(lang, "bot.compare.title", d.From, d.To)}
+	if d.Empty() {
+		return append(msg, b.t(lang, "bot.compare.unchanged"))
+	}
+	for _, bd := range d.Banks {
+		msg = append(msg, b.t(lang, "bot.compare.bank_"+string(bd.Status), bd.Bank))
+		for _, c := range bd.CategoriesAdded {
+			msg = append(msg, b.t(lang, "bot.compare.added", c.Category, c.Percent))
+		}
+		for _, c := range bd.CategoriesRemoved {
+			msg = append(msg, b.t(lang, "bot.compare.removed", c.Category, c.Percent))
+		}
+		for _, c := range bd.PercentChanged {
+			msg = append(msg, b.t(lang, "bot.compare.changed", c.Category, c.OldPercent, c.NewPercent))
+		}
+	}
+	return msg
+}
@@ -0,0 +1,159 @@
+// internal/bot/confirm.go
+package bot
+
+import (
+	"cashback-tracker/internal/diff"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// callback_data кнопок подтверждения записи
+const (
+	confirmApply  = "confirm:apply"
+	confirmCancel = "confirm:cancel"
+)
+
+// pendingTTL — сколько ждём подтверждения, потом изменение забывается
+const pendingTTL = 10 * time.Minute
+
+// pendingWrite — изменение месяца, ожидающее подтверждения пользователя
+type pendingWrite struct {
+	month   string
+	banks   []domain.BankWithCategories
+	mode    diff.Mode
+	version int // версия месяца, по которой построен список изменений
+	expires time.Time
+}
+
+// pendingWrites хранит по одному неподтверждённому изменению на пользователя.
+// Живёт в памяти: после перезапуска бота подтверждение придётся запросить заново.
+type pendingWrites struct {
+	mu     sync.Mutex
+	byUser map[int64]pendingWrite
+}
+
+func newPendingWrites() *pendingWrites {
+	return &pendingWrites{byUser: make(map[int64]pendingWrite)}
+}
+
+func (p *pendingWrites) put(userID int64, w pendingWrite) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for id, old := range p.byUser {
+		if now.After(old.expires) {
+			delete(p.byUser, id)
+		}
+	}
+	p.byUser[userID] = w
+}
+
+// take забирает изменение пользователя; false, если его нет или оно устарело
+func (p *pendingWrites) take(userID int64) (pendingWrite, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w, ok := p.byUser[userID]
+	delete(p.byUser, userID)
+	if !ok || time.Now().After(w.expires) {
+		return pendingWrite{}, false
+	}
+	return w, true
+}
+
+// handleAdd добавляет банк в текущий месяц. Если запись перезапишет сохранённые
+// проценты, вместо неё возвращается список изменений и confirm = true.
+func (b *Bot) handleAdd(ctx context.Context, lang i18n.Lang, userID int64, input string) (reply Message, confirm bool, err error) {
+	banks, err := parseAddInput(lang, input)
+	if err != nil {
+		return nil, false, err
+	}
+	month := time.Now().Format("2006-01")
+
+	current, err := b.store.GetMonth(ctx, userID, month)
+	if err != nil {
+		return nil, false, err
+	}
+	var currentBanks []domain.BankWithCategories
+	var version int
+	if current != nil {
+		currentBanks, version = current.Banks, current.Version
+	}
+	plan := diff.NewPlan(diff.Changes(currentBanks, banks, diff.Merge))
+	if plan.Counts.Updated == 0 {
+		if _, err := b.store.PatchMonth(ctx, userID, month, banks); err != nil {
+			return nil, false, err
+		}
+		return Message{b.t(lang, "bot.add.done")}, false, nil
+	}
+
+	b.pending.put(userID, pendingWrite{month: month, banks: banks, mode: diff.Merge, version: version, expires: time.Now().Add(pendingTTL)})
+	return b.formatPlan(lang, month, plan), true, nil
+}
+
+// formatPlan — текст запроса подтверждения: что будет вставлено, изменено и удалено
+func (b *Bot) formatPlan(lang i18n.Lang, month string, plan diff.Plan) Message {
+	msg := Message{b.t(lang, "bot.confirm.title", month)}
+	for _, c := range plan.Inserted {
+		msg = append(msg, b.t(lang, "bot.confirm.inserted", c.Bank, c.Category, *c.NewPercent))
+	}
+	for _, c := range plan.Updated {
+		msg = append(msg, b.t(lang, "bot.confirm.updated", c.Bank, c.Category, *c.OldPercent, *c.NewPercent))
+	}
+	for _, c := range plan.Deleted {
+		msg = append(msg, b.t(lang, "bot.confirm.deleted", c.Bank, c.Category, *c.OldPercent))
+	}
+	return msg
+}
+
+// sendConfirm отправляет запрос подтверждения с кнопками «Применить» и «Отмена».
+// Кнопки прикрепляются к последней части, если текст пришлось разбить.
+func (b *Bot) sendConfirm(ctx context.Context, chatID int64, lang i18n.Lang, msg Message) {
+	chunks := Split(msg, MaxMessageLength)
+	for i, chunk := range chunks {
+		out := tgbotapi.NewMessage(chatID, chunk)
+		out.ParseMode = b.render.mode
+		if i == len(chunks)-1 {
+			out.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "bot.confirm.apply"), confirmApply),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "bot.confirm.cancel"), confirmCancel),
+			))
+		}
+		if _, err := b.sender.Send(ctx, out); err != nil {
+			return
+		}
+	}
+}
+
+// handleConfirm применяет или отменяет ожидающее изменение
+func (b *Bot) handleConfirm(ctx context.Context, lang i18n.Lang, userID int64, data string) (Message, error) {
+	w, ok := b.pending.take(userID)
+	if !ok {
+		return Message{b.t(lang, "bot.confirm.expired")}, nil
+	}
+	if data == confirmCancel {
+		return Message{b.t(lang, "bot.confirm.cancelled")}, nil
+	}
+
+	// пока пользователь думал, месяц могли изменить: тогда показанный список уже неверен
+	ctx = storage.WithVersion(ctx, w.version)
+	var err error
+	if w.mode == diff.Replace {
+		_, err = b.store.SaveMonth(ctx, userID, w.month, w.banks)
+	} else {
+		_, err = b.store.PatchMonth(ctx, userID, w.month, w.banks)
+	}
+	if errors.Is(err, storage.ErrStaleVersion) {
+		return Message{b.t(lang, "bot.confirm.stale")}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Message{b.t(lang, "bot.add.done")}, nil
+}
@@ -0,0 +1,87 @@
+// internal/bot/deadlines.go
+package bot
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// deadlineLines — строки о сроках выбора категорий для ответа на /month
+func (b *Bot) deadlineLines(ctx context.Context, lang i18n.Lang, userID int64) Message {
+	deadlines, err := b.deadlines.Upcoming(ctx, userID, time.Now())
+	if err != nil {
+		// сроки — дополнение к /month, без них ответ всё равно полезен
+		slog.Error("Не удалось посчитать сроки выбора категорий", "error", err, "user_id", userID)
+		return nil
+	}
+	if len(deadlines) == 0 {
+		return nil
+	}
+
+	msg := Message{b.t(lang, "bot.deadlines.title")}
+	for _, d := range deadlines {
+		if d.Filled {
+			msg = append(msg, b.t(lang, "bot.deadlines.filled", d.Bank, d.Month, formatDate(d.Date)))
+		} else {
+			msg = append(msg, b.t(lang, "bot.deadlines.pending", d.Bank, d.Month, formatDate(d.Date), d.DaysLeft))
+		}
+	}
+	return msg
+}
+
+// DeadlineJob — обработчик ежедневной задачи: предупреждает пользователей о банках,
+// по которым срок выбора наступает в ближайшие alertDays дней, а категории не выбраны.
+// Про каждый банк и месяц предупреждаем один раз.
+func (b *Bot) DeadlineJob(alertDays int) func(ctx context.Context, job domain.Job) (time.Time, error) {
+	return func(ctx context.Context, job domain.Job) (time.Time, error) {
+		now := time.Now()
+		users, err := b.store.UsersWithDeadlineRules(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, userID := range users {
+			if err := b.alertDeadlines(ctx, userID, now, alertDays); err != nil {
+				slog.Error("Не удалось предупредить о сроках", "error", err, "user_id", userID)
+			}
+		}
+		return b.deadlines.NextRunAt(now), nil
+	}
+}
+
+func (b *Bot) alertDeadlines(ctx context.Context, userID int64, now time.Time, alertDays int) error {
+	pending, err := b.deadlines.Pending(ctx, userID, now, alertDays)
+	if err != nil {
+		return err
+	}
+
+	lang := b.language(ctx, userID, "")
+	var msg Message
+	for _, d := range pending {
+		fresh, err := b.store.MarkDeadlineAlerted(ctx, userID, d.Bank, d.Month)
+		if err != nil {
+			return err
+		}
+		if fresh {
+			msg = append(msg, b.t(lang, "bot.deadlines.pending", d.Bank, d.Month, formatDate(d.Date), d.DaysLeft))
+		}
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+
+	// в личном чате chat_id совпадает с user_id
+	b.send(ctx, userID, append(Message{b.t(lang, "bot.deadlines.alert")}, msg...))
+	return nil
+}
+
+// formatDate показывает дату YYYY-MM-DD как ДД.ММ.ГГГГ
+func formatDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.Format("02.01.2006")
+}
@@ -0,0 +1,173 @@
+// internal/bot/dispatcher.go
+package bot
+
+import (
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	ErrQueueFull        = errors.New("update queue is full")
+	ErrDispatcherClosed = errors.New("dispatcher is closed")
+)
+
+type UpdateHandler interface {
+	HandleUpdate(ctx context.Context, update tgbotapi.Update)
+}
+
+// Dispatcher раздаёт обновления пулу воркеров. Все обновления одного чата
+// попадают в одну очередь, поэтому внутри чата порядок сохраняется,
+// а разные чаты обрабатываются параллельно.
+type Dispatcher struct {
+	handler UpdateHandler
+	queues  []chan tgbotapi.Update
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+
+	// ctx передаётся в обработчики и отменяется, только если Shutdown не дождался очереди
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewDispatcher запускает workers воркеров; queueSize — общий размер очереди на всех
+func NewDispatcher(handler UpdateHandler, workers, queueSize int) *Dispatcher {
+	workers = max(workers, 1)
+	perWorker := max(queueSize/workers, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		handler: handler,
+		queues:  make([]chan tgbotapi.Update, workers),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, perWorker)
+		d.wg.Add(1)
+		go d.worker(d.queues[i])
+	}
+	return d
+}
+
+// Enqueue ставит обновление в очередь без ожидания; ErrQueueFull, если места нет
+func (d *Dispatcher) Enqueue(update tgbotapi.Update) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+	select {
+	case d.queueFor(update) <- update:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Submit ждёт места в очереди — так long polling не читает новые обновления быстрее, чем успевает обработать
+func (d *Dispatcher) Submit(ctx context.Context, update tgbotapi.Update) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+	select {
+	case d.queueFor(update) <- update:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown перестаёт принимать обновления и ждёт, пока воркеры разберут очередь.
+// Если ctx истёк раньше, обработчики получают отменённый контекст.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, q := range d.queues {
+			close(q)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) worker(queue <-chan tgbotapi.Update) {
+	defer d.wg.Done()
+	for update := range queue {
+		d.process(update)
+	}
+}
+
+func (d *Dispatcher) process(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Паника при обработке обновления", "panic", r, "update_id", update.UpdateID)
+		}
+	}()
+	d.handler.HandleUpdate(d.ctx, update)
+}
+
+func (d *Dispatcher) queueFor(update tgbotapi.Update) chan tgbotapi.Update {
+	key := uint64(chatKey(update))
+	return d.queues[key%uint64(len(d.queues))]
+}
+
+// chatKey — ключ упорядочивания: чат, а для обновлений без чата — сам update_id
+func chatKey(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.From != nil:
+		return update.CallbackQuery.From.ID
+	default:
+		return int64(update.UpdateID)
+	}
+}
+
+// deduplicated пропускает обновления, чей update_id уже обрабатывался
+type deduplicated struct {
+	next    UpdateHandler
+	updates storage.UpdateStorage
+}
+
+// Deduplicate оборачивает обработчик проверкой update_id через постоянное хранилище
+func Deduplicate(next UpdateHandler, updates storage.UpdateStorage) UpdateHandler {
+	return &deduplicated{next: next, updates: updates}
+}
+
+func (d *deduplicated) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
+	fresh, err := d.updates.MarkUpdateProcessed(ctx, int64(update.UpdateID))
+	if err != nil {
+		// без БД обработчик всё равно не сработает, но хотя бы ответит ошибкой
+		slog.Error("Не удалось проверить update_id", "error", err, "update_id", update.UpdateID)
+	} else if !fresh {
+		slog.Info("Повторная доставка обновления пропущена", "update_id", update.UpdateID)
+		return
+	}
+	d.next.HandleUpdate(ctx, update)
+}
@@ -0,0 +1,62 @@
+// internal/bot/history.go
+package bot
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// handleHistoryCategory: "<категория> [минимальный процент]" — где был кэшбэк по категории за 12 месяцев
+func (b *Bot) handleHistoryCategory(ctx context.Context, lang i18n.Lang, userID int64, args string) (Message, error) {
+	fields := strings.Fields(args)
+	var minPercent float64
+	if len(fields) > 1 {
+		if p, err := strconv.ParseFloat(strings.Replace(fields[len(fields)-1], ",", ".", 1), 32); err == nil {
+			minPercent = p
+			fields = fields[:len(fields)-1]
+		}
+	}
+	if len(fields) == 0 {
+		return Message{b.t(lang, "bot.history.usage")}, nil
+	}
+	category := strings.Join(fields, " ")
+
+	now := time.Now()
+	rows, err := b.store.SearchHistory(ctx, userID, domain.HistoryFilter{
+		Category:   category,
+		From:       now.AddDate(0, -11, 0).Format("2006-01"),
+		To:         now.Format("2006-01"),
+		MinPercent: float32(minPercent),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		notFound := Message{b.t(lang, "bot.history.not_found", category)}
+		return b.didYouMean(ctx, lang, userID, domain.NameCategory, category, "/history_cat", notFound), nil
+	}
+
+	// строки уже отсортированы по месяцу, внутри месяца — по убыванию процента
+	msg := Message{b.t(lang, "bot.history.title", category)}
+	var month string
+	var banks []string
+	flush := func() {
+		if month != "" {
+			msg = append(msg, b.t(lang, "bot.history.month", month, strings.Join(banks, ", ")))
+		}
+	}
+	for _, r := range rows {
+		if r.Month != month {
+			flush()
+			month, banks = r.Month, nil
+		}
+		banks = append(banks, fmt.Sprintf("%s %.1f%%", r.Bank, r.Percent))
+	}
+	flush()
+	return msg, nil
+}
@@ -0,0 +1,179 @@
+// internal/bot/reminders.go
+package bot
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/reminder"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// copyMonthPrefix — callback_data кнопки «Скопировать прошлый месяц», дальше идёт YYYY-MM
+const copyMonthPrefix = "copy_month:"
+
+// handleRemind: без аргументов показывает настройки, "off" выключает,
+// "<день> <ЧЧ:ММ> [часовой пояс]" сохраняет
+func (b *Bot) handleRemind(ctx context.Context, lang i18n.Lang, userID int64, args string) (Message, error) {
+	fields := strings.Fields(args)
+
+	switch {
+	case len(fields) == 0:
+		settings, err := b.reminders.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if settings == nil {
+			return Message{b.t(lang, "bot.remind.none"), b.t(lang, "bot.remind.usage")}, nil
+		}
+		if !settings.Enabled {
+			return Message{b.t(lang, "bot.remind.disabled"), b.t(lang, "bot.remind.usage")}, nil
+		}
+		return Message{b.t(lang, "bot.remind.current", settings.Day, settings.Time, settings.Timezone)}, nil
+
+	case len(fields) == 1 && fields[0] == "off":
+		if err := b.reminders.Disable(ctx, userID); err != nil {
+			return nil, err
+		}
+		return Message{b.t(lang, "bot.remind.disabled")}, nil
+
+	case len(fields) == 2 || len(fields) == 3:
+		day, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return Message{b.t(lang, "reminder.invalid_day")}, nil
+		}
+		settings := domain.ReminderSettings{UserID: userID, Day: day, Time: fields[1], Enabled: true}
+		if len(fields) == 3 {
+			settings.Timezone = fields[2]
+		}
+
+		next, err := b.reminders.Save(ctx, settings)
+		if key := reminder.ErrorKey(err); key != "" {
+			return Message{b.t(lang, key), b.t(lang, "bot.remind.usage")}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return Message{b.t(lang, "bot.remind.saved", next.Format("02.01.2006 15:04 MST"))}, nil
+
+	default:
+		return Message{b.t(lang, "bot.remind.usage")}, nil
+	}
+}
+
+// RemindJob — обработчик задачи планировщика: если на следующий месяц ещё ничего
+// не сохранено, присылает напоминание с кнопкой копирования прошлого месяца
+func (b *Bot) RemindJob(ctx context.Context, job domain.Job) (time.Time, error) {
+	settings, err := b.reminders.Get(ctx, job.UserID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if settings == nil || !settings.Enabled {
+		return time.Time{}, nil
+	}
+
+	month := reminder.UpcomingMonth(*settings, job.RunAt)
+	cashback, err := b.store.GetMonth(ctx, job.UserID, month)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if cashback == nil {
+		lang := b.language(ctx, job.UserID, "")
+		// в личном чате chat_id совпадает с user_id
+		out := tgbotapi.NewMessage(job.UserID, string(b.t(lang, "bot.remind.message", month)))
+		out.ParseMode = b.render.mode
+		out.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "bot.remind.copy"), copyMonthPrefix+month),
+		))
+		// недоставленное сообщение уже сохранено отправителем, повторять задачу незачем
+		if _, err := b.sender.Send(ctx, out); err == nil {
+			slog.Info("Напоминание отправлено", "user_id", job.UserID, "month", month)
+		}
+	}
+
+	return reminder.NextRun(*settings, time.Now())
+}
+
+// handleCallback обрабатывает нажатия inline-кнопок
+func (b *Bot) handleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+	lang := b.language(ctx, userID, query.From.LanguageCode)
+
+	var reply Message
+	var notice string
+	switch {
+	case strings.HasPrefix(query.Data, copyMonthPrefix):
+		var err error
+		reply, err = b.copyPreviousMonth(ctx, lang, userID, strings.TrimPrefix(query.Data, copyMonthPrefix))
+		if err != nil {
+			reply = Message{b.t(lang, "bot.error", err)}
+		}
+	case query.Data == confirmApply || query.Data == confirmCancel:
+		var err error
+		reply, err = b.handleConfirm(ctx, lang, userID, query.Data)
+		if err != nil {
+			reply = Message{b.t(lang, "bot.error", err)}
+		}
+	default:
+		notice = i18n.T(lang, "bot.unknown_command")
+	}
+
+	if _, err := b.api.Request(tgbotapi.NewCallback(query.ID, notice)); err != nil {
+		slog.Error("Не удалось ответить на callback", "error", err, "user_id", userID)
+	}
+	if query.Message == nil || reply == nil {
+		return
+	}
+
+	chatID := query.Message.Chat.ID
+	// убираем кнопку, чтобы её не нажали повторно
+	b.sender.Send(ctx, tgbotapi.NewEditMessageReplyMarkup(chatID, query.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+	b.send(ctx, chatID, reply)
+}
+
+// copyPreviousMonth переносит банки и категории прошлого месяца в month, если он ещё пуст
+func (b *Bot) copyPreviousMonth(ctx context.Context, lang i18n.Lang, userID int64, month string) (Message, error) {
+	target, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, errors.New(i18n.T(lang, "api.month_format"))
+	}
+	prev := target.AddDate(0, -1, 0).Format("2006-01")
+
+	existing, err := b.store.GetMonth(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && len(existing.Banks) > 0 {
+		return Message{b.t(lang, "bot.copy.exists", month)}, nil
+	}
+	// пока читаем прошлый месяц, этот могут заполнить (повторное нажатие, /add, API):
+	// запись пройдёт, только если месяц всё ещё в том виде, в каком мы его видели
+	version := 0
+	if existing != nil {
+		version = existing.Version
+	}
+
+	source, err := b.store.GetMonth(ctx, userID, prev)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil || len(source.Banks) == 0 {
+		return Message{b.t(lang, "bot.copy.empty", prev)}, nil
+	}
+
+	if _, err := b.store.SaveMonth(storage.WithVersion(ctx, version), userID, month, source.Banks); err != nil {
+		if errors.Is(err, storage.ErrStaleVersion) {
+			return Message{b.t(lang, "bot.copy.exists", month)}, nil
+		}
+		return nil, err
+	}
+	return Message{b.t(lang, "bot.copy.done", prev, month, len(source.Banks))}, nil
+}
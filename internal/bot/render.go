@@ -0,0 +1,361 @@
+// internal/bot/render.go
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxMessageLength — лимит Telegram на длину текста одного сообщения
+const MaxMessageLength = 4096
+
+// Markup — фрагмент, уже подготовленный для выбранного ParseMode.
+// Пользовательские строки превращаются в Markup только через Renderer.
+type Markup string
+
+// Message — ответ из блоков; при отправке блоки склеиваются через "\n"
+// и раскладываются по сообщениям, не разрывая ни один блок пополам.
+type Message []Markup
+
+// Renderer экранирует текст и строит разметку для HTML, MarkdownV2 или обычного текста ("")
+type Renderer struct {
+	mode string
+}
+
+func NewRenderer(mode string) Renderer {
+	return Renderer{mode: mode}
+}
+
+var (
+	htmlEscaper       = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	markdownV2Escaper = strings.NewReplacer(
+		"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(",
+		")", "\\)", "~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+",
+		"-", "\\-", "=", "\\=", "|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+	)
+	// внутри code/pre в MarkdownV2 экранируются только ` и \
+	markdownV2CodeEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+)
+
+// Escape экранирует произвольный текст для обычной части сообщения
+func (r Renderer) Escape(s string) string {
+	switch r.mode {
+	case tgbotapi.ModeHTML:
+		return htmlEscaper.Replace(s)
+	case tgbotapi.ModeMarkdownV2:
+		return markdownV2Escaper.Replace(s)
+	default:
+		return s
+	}
+}
+
+func (r Renderer) escapeCode(s string) string {
+	switch r.mode {
+	case tgbotapi.ModeHTML:
+		return htmlEscaper.Replace(s)
+	case tgbotapi.ModeMarkdownV2:
+		return markdownV2CodeEscaper.Replace(s)
+	default:
+		return s
+	}
+}
+
+func (r Renderer) Text(s string) Markup {
+	return Markup(r.Escape(s))
+}
+
+func (r Renderer) Bold(m Markup) Markup {
+	switch r.mode {
+	case tgbotapi.ModeHTML:
+		return "<b>" + m + "</b>"
+	case tgbotapi.ModeMarkdownV2:
+		return "*" + m + "*"
+	default:
+		return m
+	}
+}
+
+func (r Renderer) Code(s string) Markup {
+	switch r.mode {
+	case tgbotapi.ModeHTML:
+		return Markup("<code>" + r.escapeCode(s) + "</code>")
+	case tgbotapi.ModeMarkdownV2:
+		return Markup("`" + r.escapeCode(s) + "`")
+	default:
+		return Markup(s)
+	}
+}
+
+func (r Renderer) Pre(s string) Markup {
+	switch r.mode {
+	case tgbotapi.ModeHTML:
+		return Markup("<pre>" + r.escapeCode(s) + "</pre>")
+	case tgbotapi.ModeMarkdownV2:
+		return Markup("```\n" + r.escapeCode(s) + "\n```")
+	default:
+		return Markup(s)
+	}
+}
+
+// Format подставляет аргументы в доверенный шаблон из каталога сообщений.
+// В шаблоне *текст* — жирный, `текст` — моноширинный. Строковые аргументы
+// (string, error) экранируются по правилам своего участка, Markup вставляется как есть,
+// остальные аргументы форматируются fmt'ом. Для строк не используйте %q.
+func (r Renderer) Format(template string, args ...any) Markup {
+	var values []any
+	fmtArgs := make([]any, len(args))
+	for i, a := range args {
+		switch v := a.(type) {
+		case Markup, string:
+			fmtArgs[i] = placeholder(len(values))
+			values = append(values, v)
+		case error:
+			fmtArgs[i] = placeholder(len(values))
+			values = append(values, v.Error())
+		default:
+			fmtArgs[i] = a
+		}
+	}
+	text := template
+	if len(args) > 0 || strings.Contains(template, "%%") {
+		text = fmt.Sprintf(template, fmtArgs...)
+	}
+
+	var sb strings.Builder
+	for _, seg := range splitMarkup(text) {
+		escape := r.Escape
+		if seg.code {
+			escape = r.escapeCode
+		}
+		body := Markup(substitute(seg.text, values, escape))
+		switch {
+		case seg.code:
+			body = r.wrapCode(body)
+		case seg.bold:
+			body = r.Bold(body)
+		}
+		sb.WriteString(string(body))
+	}
+	return Markup(sb.String())
+}
+
+func (r Renderer) wrapCode(m Markup) Markup {
+	switch r.mode {
+	case tgbotapi.ModeHTML:
+		return "<code>" + m + "</code>"
+	case tgbotapi.ModeMarkdownV2:
+		return "`" + m + "`"
+	default:
+		return m
+	}
+}
+
+func placeholder(i int) string {
+	return "\x00" + strconv.Itoa(i) + "\x00"
+}
+
+type segment struct {
+	text       string
+	bold, code bool
+}
+
+// splitMarkup режет шаблон по маркерам * и ` (внутри `...` звёздочка — обычный символ)
+func splitMarkup(s string) []segment {
+	var segs []segment
+	var cur strings.Builder
+	bold, code := false, false
+	flush := func() {
+		if cur.Len() > 0 {
+			segs = append(segs, segment{text: cur.String(), bold: bold, code: code})
+			cur.Reset()
+		}
+	}
+	for _, ch := range s {
+		switch {
+		case ch == '`':
+			flush()
+			code = !code
+		case ch == '*' && !code:
+			flush()
+			bold = !bold
+		default:
+			cur.WriteRune(ch)
+		}
+	}
+	flush()
+	return segs
+}
+
+// substitute экранирует литеральный текст и подставляет значения вместо плейсхолдеров
+func substitute(s string, values []any, escape func(string) string) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(s, 0)
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start+1:], 0)
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		idx, err := strconv.Atoi(s[start+1 : end])
+		if err != nil || idx >= len(values) {
+			break
+		}
+		sb.WriteString(escape(s[:start]))
+		switch v := values[idx].(type) {
+		case Markup:
+			sb.WriteString(string(v))
+		case string:
+			sb.WriteString(escape(v))
+		}
+		s = s[end+1:]
+	}
+	sb.WriteString(escape(s))
+	return sb.String()
+}
+
+// Table строит моноширинную таблицу; если она не влезает в одно сообщение,
+// возвращается несколько блоков, и в каждом повторяется заголовок
+func (r Renderer) Table(header []string, rows [][]string) Message {
+	widths := make([]int, len(header))
+	measure := func(cells []string) {
+		for i, c := range cells {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(c))
+			}
+		}
+	}
+	measure(header)
+	for _, row := range rows {
+		measure(row)
+	}
+
+	formatRow := func(cells []string) string {
+		parts := make([]string, len(widths))
+		for i := range widths {
+			c := ""
+			if i < len(cells) {
+				c = cells[i]
+			}
+			parts[i] = c + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c))
+		}
+		return strings.TrimRight(strings.Join(parts, "  "), " ")
+	}
+
+	sep := make([]string, len(widths))
+	for i, w := range widths {
+		sep[i] = strings.Repeat("─", w)
+	}
+	head := formatRow(header) + "\n" + strings.Join(sep, "──")
+
+	// запас под обёртку <pre>/``` и экранирование
+	const budget = MaxMessageLength - 64
+	var blocks Message
+	cur := head
+	for _, row := range rows {
+		line := formatRow(row)
+		if textLen(r.escapeCode(cur+"\n"+line)) > budget && cur != head {
+			blocks = append(blocks, r.Pre(cur))
+			cur = head
+		}
+		cur += "\n" + line
+	}
+	return append(blocks, r.Pre(cur))
+}
+
+// Split раскладывает блоки по сообщениям не длиннее limit
+func Split(msg Message, limit int) []string {
+	var chunks []string
+	var cur strings.Builder
+	for _, block := range msg {
+		for _, part := range hardSplit(string(block), limit) {
+			if cur.Len() > 0 && textLen(cur.String())+1+textLen(part) > limit {
+				chunks = append(chunks, cur.String())
+				cur.Reset()
+			}
+			if cur.Len() > 0 {
+				cur.WriteByte('\n')
+			}
+			cur.WriteString(part)
+		}
+	}
+	if cur.Len() > 0 {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks
+}
+
+// hardSplit режет слишком длинный блок по строкам, а строку — по символам,
+// не разрывая HTML-сущности и экранирующие обратные слэши.
+// Блоки с тегами, длиннее лимита, должны строиться заранее по частям (как Table).
+func hardSplit(s string, limit int) []string {
+	if textLen(s) <= limit {
+		return []string{s}
+	}
+	var parts []string
+	var cur strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		for textLen(line) > limit {
+			cut := cutIndex(line, limit)
+			if cur.Len() > 0 {
+				parts = append(parts, cur.String())
+				cur.Reset()
+			}
+			parts = append(parts, line[:cut])
+			line = line[cut:]
+		}
+		if cur.Len() > 0 && textLen(cur.String())+1+textLen(line) > limit {
+			parts = append(parts, cur.String())
+			cur.Reset()
+		}
+		if cur.Len() > 0 {
+			cur.WriteByte('\n')
+		}
+		cur.WriteString(line)
+	}
+	if cur.Len() > 0 {
+		parts = append(parts, cur.String())
+	}
+	return parts
+}
+
+func cutIndex(s string, limit int) int {
+	n, cut := 0, 0
+	for i, ch := range s {
+		n += textLen(string(ch))
+		if n > limit {
+			break
+		}
+		cut = i + utf8.RuneLen(ch)
+	}
+	// не оставляем висящий "\" (MarkdownV2) и не режем "&amp;" (HTML)
+	for cut > 0 && s[cut-1] == '\\' {
+		cut--
+	}
+	if amp := strings.LastIndexByte(s[:cut], '&'); amp >= 0 && !strings.Contains(s[amp:cut], ";") && cut-amp < 8 {
+		cut = amp
+	}
+	if cut == 0 {
+		cut = len(s)
+	}
+	return cut
+}
+
+// textLen — длина в UTF-16 единицах, именно так Telegram считает лимит
+func textLen(s string) int {
+	n := 0
+	for _, ch := range s {
+		if ch >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
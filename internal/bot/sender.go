@@ -0,0 +1,218 @@
+// internal/bot/sender.go
+package bot
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Лимиты Telegram: около 30 сообщений в секунду на бота и одно в секунду в один чат
+const (
+	globalSendInterval = time.Second / 30
+	chatSendInterval   = time.Second
+
+	maxSendAttempts = 5
+	baseRetryDelay  = 500 * time.Millisecond
+	maxRetryDelay   = 30 * time.Second
+)
+
+// Sender — единая точка отправки сообщений: соблюдает лимиты Telegram,
+// повторяет отправку при 429 и 5xx, а недоставленное сохраняет в dead letters
+type Sender struct {
+	api         *tgbotapi.BotAPI
+	deadLetters storage.DeadLetterStorage
+
+	global *pacer
+	mu     sync.Mutex
+	chats  map[int64]*pacer
+}
+
+func NewSender(api *tgbotapi.BotAPI, deadLetters storage.DeadLetterStorage) *Sender {
+	return &Sender{
+		api:         api,
+		deadLetters: deadLetters,
+		global:      &pacer{interval: globalSendInterval},
+		chats:       make(map[int64]*pacer),
+	}
+}
+
+// Send отправляет сообщение, дожидаясь своей очереди по лимитам.
+// Если все попытки исчерпаны или ошибка постоянная, сообщение попадает в dead letters.
+func (s *Sender) Send(ctx context.Context, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	chatID := chatIDOf(c)
+
+	var lastErr error
+	attempt := 0
+	for attempt < maxSendAttempts {
+		attempt++
+		if err := s.wait(ctx, chatID); err != nil {
+			lastErr = err
+			break
+		}
+
+		msg, err := s.api.Send(c)
+		if err == nil {
+			return msg, nil
+		}
+		lastErr = err
+
+		delay, retry := retryDelay(err, attempt)
+		if !retry || attempt == maxSendAttempts {
+			break
+		}
+		slog.Warn("Повторяем отправку в Telegram", "error", err, "chat_id", chatID, "attempt", attempt, "delay", delay)
+		if err := sleep(ctx, delay); err != nil {
+			lastErr = err
+			break
+		}
+	}
+
+	s.saveDeadLetter(chatID, c, lastErr, attempt)
+	return tgbotapi.Message{}, fmt.Errorf("send to chat %d: %w", chatID, lastErr)
+}
+
+func (s *Sender) wait(ctx context.Context, chatID int64) error {
+	if err := s.global.wait(ctx); err != nil {
+		return err
+	}
+	if chatID == 0 {
+		return nil
+	}
+	return s.chat(chatID).wait(ctx)
+}
+
+func (s *Sender) chat(chatID int64) *pacer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.chats[chatID]
+	if !ok {
+		// чаты, в которые давно не писали, больше не ограничивают — выбрасываем их
+		if len(s.chats) >= 1024 {
+			now := time.Now()
+			for id, old := range s.chats {
+				if old.idle(now) {
+					delete(s.chats, id)
+				}
+			}
+		}
+		p = &pacer{interval: chatSendInterval}
+		s.chats[chatID] = p
+	}
+	return p
+}
+
+// saveDeadLetter пишет сообщение в dead letters; контекст отдельный,
+// чтобы запись прошла и при отменённом контексте отправки
+func (s *Sender) saveDeadLetter(chatID int64, c tgbotapi.Chattable, sendErr error, attempts int) {
+	slog.Error("Сообщение не доставлено", "error", sendErr, "chat_id", chatID, "attempts", attempts)
+	if s.deadLetters == nil {
+		return
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		payload = []byte("null")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	letter := domain.DeadLetter{
+		ChatID:   chatID,
+		Kind:     fmt.Sprintf("%T", c),
+		Payload:  payload,
+		Error:    sendErr.Error(),
+		Attempts: attempts,
+	}
+	if err := s.deadLetters.SaveDeadLetter(ctx, letter); err != nil {
+		slog.Error("Не удалось сохранить недоставленное сообщение", "error", err, "chat_id", chatID)
+	}
+}
+
+// retryDelay решает, стоит ли повторять: 429 — через retry_after, 5xx и сетевые
+// ошибки — с экспоненциальной задержкой, остальные ответы Telegram — постоянные
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) {
+		switch {
+		case tgErr.Code == 429 && tgErr.RetryAfter > 0:
+			return time.Duration(tgErr.RetryAfter) * time.Second, true
+		case tgErr.Code == 429 || tgErr.Code >= 500:
+			return backoff(attempt), true
+		default:
+			return 0, false
+		}
+	}
+	return backoff(attempt), true
+}
+
+func backoff(attempt int) time.Duration {
+	return min(baseRetryDelay<<(attempt-1), maxRetryDelay)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chatIDOf достаёт чат получателя из конфигов, которые отправляет бот
+func chatIDOf(c tgbotapi.Chattable) int64 {
+	switch v := c.(type) {
+	case tgbotapi.MessageConfig:
+		return v.ChatID
+	case tgbotapi.DocumentConfig:
+		return v.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return v.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return v.ChatID
+	default:
+		return 0
+	}
+}
+
+// pacer выдаёт слоты не чаще одного за interval
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (p *pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	now := time.Now()
+	slot := p.next
+	if slot.Before(now) {
+		slot = now
+	}
+	p.next = slot.Add(p.interval)
+	p.mu.Unlock()
+
+	if d := slot.Sub(now); d > 0 {
+		return sleep(ctx, d)
+	}
+	return nil
+}
+
+func (p *pacer) idle(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.next.Before(now)
+}
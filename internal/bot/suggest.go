@@ -0,0 +1,30 @@
+// internal/bot/suggest.go
+package bot
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"context"
+	"log/slog"
+)
+
+const maxSuggestions = 5
+
+// didYouMean дополняет ответ «ничего не найдено» похожими названиями
+// в виде готовых команд; без подсказок возвращает notFound как есть
+func (b *Bot) didYouMean(ctx context.Context, lang i18n.Lang, userID int64, kind domain.NameKind, query, command string, notFound Message) Message {
+	names, err := b.store.SuggestNames(ctx, userID, kind, query, maxSuggestions)
+	if err != nil {
+		slog.Error("Не удалось подобрать подсказки", "error", err, "user_id", userID, "query", query)
+		return notFound
+	}
+	if len(names) == 0 {
+		return notFound
+	}
+
+	msg := append(notFound, b.t(lang, "bot.suggest.title"))
+	for _, name := range names {
+		msg = append(msg, b.render.Format("- `%s`", command+" "+name))
+	}
+	return msg
+}
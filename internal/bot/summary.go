@@ -0,0 +1,178 @@
+// internal/bot/summary.go
+package bot
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/fuzzy"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/summary"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (b *Bot) handleSummary(ctx context.Context, lang i18n.Lang, userID int64, args string) (Message, error) {
+	month := strings.TrimSpace(args)
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return Message{b.t(lang, "bot.summary.usage")}, nil
+	}
+
+	s, err := b.summaries.Build(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	return b.formatSummary(lang, s), nil
+}
+
+func (b *Bot) formatSummary(lang i18n.Lang, s *summary.MonthSummary) Message {
+	if s.BankCount == 0 {
+		return Message{b.t(lang, "bot.summary.empty", s.Month)}
+	}
+
+	msg := Message{
+		b.t(lang, "bot.summary.title", s.Month),
+		b.t(lang, "bot.summary.counts", s.BankCount, s.CategoryCount),
+	}
+
+	if s.PurchasesLogged {
+		msg = append(msg, b.t(lang, "bot.summary.earned", s.Spent, s.Earned))
+		for _, c := range s.ByCategory {
+			msg = append(msg, b.t(lang, "bot.summary.category", c.Bank, c.Category, c.Percent, c.Spent, c.Earned))
+		}
+		if len(s.Unused) > 0 {
+			msg = append(msg, b.t(lang, "bot.summary.unused"))
+			for _, r := range s.Unused {
+				msg = append(msg, b.t(lang, "bot.summary.row", r.Bank, r.Category, r.Percent))
+			}
+		}
+	} else {
+		msg = append(msg, b.t(lang, "bot.summary.no_purchases"))
+	}
+
+	cmp := s.Comparison
+	msg = append(msg, b.t(lang, "bot.summary.compare", s.PreviousMonth))
+	if len(cmp.Added)+len(cmp.Removed)+len(cmp.Changed) == 0 {
+		return append(msg, b.t(lang, "bot.summary.unchanged"))
+	}
+	for _, r := range cmp.Added {
+		msg = append(msg, b.t(lang, "bot.summary.added", r.Bank, r.Category, r.Percent))
+	}
+	for _, r := range cmp.Removed {
+		msg = append(msg, b.t(lang, "bot.summary.removed", r.Bank, r.Category, r.Percent))
+	}
+	for _, c := range cmp.Changed {
+		msg = append(msg, b.t(lang, "bot.summary.changed", c.Bank, c.Category, c.OldPercent, c.NewPercent))
+	}
+	return msg
+}
+
+// handleSpent записывает покупку: "<банк> <категория> <сумма> [YYYY-MM-DD]"
+// или, как в /add, "<банк>: <категория> <сумма> [YYYY-MM-DD]"
+func (b *Bot) handleSpent(ctx context.Context, lang i18n.Lang, userID int64, args string) (Message, error) {
+	bank, rest, explicit := strings.Cut(args, ":")
+	if !explicit {
+		rest = args
+	}
+	fields := strings.Fields(rest)
+	date := time.Now().Format("2006-01-02")
+	if len(fields) > 0 && strings.Count(fields[len(fields)-1], "-") == 2 {
+		if _, err := time.Parse("2006-01-02", fields[len(fields)-1]); err != nil {
+			return Message{b.t(lang, "bot.spent.bad_date")}, nil
+		}
+		date = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+	minFields := 3
+	if explicit {
+		minFields = 2
+	}
+	if len(fields) < minFields || (explicit && strings.TrimSpace(bank) == "") {
+		return Message{b.t(lang, "bot.spent.usage")}, nil
+	}
+
+	amountStr := fields[len(fields)-1]
+	amount, err := strconv.ParseFloat(strings.Replace(amountStr, ",", ".", 1), 64)
+	if err != nil || amount <= 0 {
+		return Message{b.t(lang, "bot.spent.bad_amount", amountStr)}, nil
+	}
+	fields = fields[:len(fields)-1]
+
+	// названия берём в том написании, в каком они уже есть в месяце покупки,
+	// иначе итоги не сопоставят покупку с кэшбэком
+	cashback, err := b.store.GetMonth(ctx, userID, date[:7])
+	if err != nil {
+		return nil, err
+	}
+	var banks []domain.BankWithCategories
+	if cashback != nil {
+		banks = cashback.Banks
+	}
+	if !explicit {
+		bank, fields = splitBank(banks, fields)
+	}
+	purchase := domain.Purchase{
+		Date:     date,
+		Bank:     strings.TrimSpace(bank),
+		Category: strings.Join(fields, " "),
+		Amount:   amount,
+	}
+	for _, bwc := range banks {
+		if fuzzy.Normalize(bwc.Bank.Name) != fuzzy.Normalize(purchase.Bank) {
+			continue
+		}
+		purchase.Bank = bwc.Bank.Name
+		for _, cc := range bwc.Categories {
+			if fuzzy.Normalize(cc.Category.Name) == fuzzy.Normalize(purchase.Category) {
+				purchase.Category = cc.Category.Name
+			}
+		}
+	}
+	if err := b.store.AddPurchase(ctx, userID, purchase); err != nil {
+		return nil, err
+	}
+	return Message{b.t(lang, "bot.spent.done", purchase.Bank, purchase.Category, purchase.Amount)}, nil
+}
+
+// DigestJob — обработчик ежемесячной задачи: 1-го числа присылает итоги
+// прошедшего месяца всем, у кого за него есть данные
+func (b *Bot) DigestJob(ctx context.Context, job domain.Job) (time.Time, error) {
+	loc := summary.Location()
+	runAt := job.RunAt.In(loc)
+	month := time.Date(runAt.Year(), runAt.Month()-1, 1, 0, 0, 0, 0, loc).Format("2006-01")
+
+	users, err := b.store.UsersWithMonth(ctx, month)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, userID := range users {
+		s, err := b.summaries.Build(ctx, userID, month)
+		if err != nil {
+			slog.Error("Не удалось собрать итоги месяца", "error", err, "user_id", userID, "month", month)
+			continue
+		}
+		lang := b.language(ctx, userID, "")
+		// в личном чате chat_id совпадает с user_id
+		b.send(ctx, userID, b.formatSummary(lang, s))
+	}
+	return summary.NextRunAt(time.Now()), nil
+}
+
+// splitBank отделяет название банка от категории, когда двоеточия нет: банком
+// считается самое длинное начало fields, совпадающее с банком месяца, а если
+// такого нет — первое слово. Категорией должно остаться хотя бы одно слово.
+func splitBank(banks []domain.BankWithCategories, fields []string) (string, []string) {
+	for n := len(fields) - 1; n > 1; n-- {
+		name := fuzzy.Normalize(strings.Join(fields[:n], " "))
+		for _, bwc := range banks {
+			if fuzzy.Normalize(bwc.Bank.Name) == name {
+				return bwc.Bank.Name, fields[n:]
+			}
+		}
+	}
+	return fields[0], fields[1:]
+}
@@ -2,15 +2,32 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// webhookSecretPattern — символы, которые Telegram принимает в secret_token
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
 type Config struct {
 	ServerPort   string
 	DBConn       string
 	JWTSecret    string
 	JWTExpiresIn time.Duration
+
+	WebhookURL    string // внешний адрес сервиса без пути
+	WebhookPath   string
+	WebhookSecret string
+
+	BotWorkers   int
+	BotQueueSize int
+
+	DeadlineAlertDays int
 }
 
 func MustLoad() Config {
@@ -37,11 +54,63 @@ func MustLoad() Config {
 		}
 	}
 
+	// ✅ Telegram webhook
+	webhookURL := os.Getenv("TELEGRAM_WEBHOOK_URL")
+	if webhookURL == "" {
+		webhookURL = os.Getenv("RENDER_EXTERNAL_URL")
+	}
+	webhookURL = strings.TrimRight(webhookURL, "/")
+
+	webhookPath := os.Getenv("TELEGRAM_WEBHOOK_PATH")
+	if webhookPath == "" {
+		webhookPath = "/telegram"
+	}
+	if !strings.HasPrefix(webhookPath, "/") {
+		webhookPath = "/" + webhookPath
+	}
+
+	// Если секрет не задан, генерируем случайный: setWebhook вызывается при каждом старте
+	webhookSecret := os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+	if webhookSecret == "" {
+		buf := make([]byte, 32)
+		_, _ = rand.Read(buf)
+		webhookSecret = hex.EncodeToString(buf)
+	}
+	// иначе setWebhook отклонит секрет и API не запустится с менее понятной ошибкой
+	if !webhookSecretPattern.MatchString(webhookSecret) {
+		panic("TELEGRAM_WEBHOOK_SECRET must be 1-256 characters A-Z, a-z, 0-9, _ or -")
+	}
+
+	// ✅ Пул обработки обновлений бота
+	botWorkers := 8
+	if n, err := strconv.Atoi(os.Getenv("BOT_WORKERS")); err == nil && n > 0 {
+		botWorkers = n
+	}
+	botQueueSize := 256
+	if n, err := strconv.Atoi(os.Getenv("BOT_QUEUE_SIZE")); err == nil && n > 0 {
+		botQueueSize = n
+	}
+
+	// ✅ За сколько дней до срока выбора категорий предупреждать
+	deadlineAlertDays := 3
+	if n, err := strconv.Atoi(os.Getenv("DEADLINE_ALERT_DAYS")); err == nil && n >= 0 {
+		deadlineAlertDays = n
+	}
+
 	// ✅ ОДИН return в конце
 	return Config{
 		ServerPort:   ":" + port,
 		DBConn:       dbConn,
 		JWTSecret:    jwtSecret,
 		JWTExpiresIn: jwtExpiresIn,
+
+		WebhookURL:    webhookURL,
+		WebhookPath:   webhookPath,
+		WebhookSecret: webhookSecret,
+
+		BotWorkers:   botWorkers,
+		BotQueueSize: botQueueSize,
+
+		DeadlineAlertDays: deadlineAlertDays,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,174 @@
+// internal/deadline/deadline.go
+package deadline
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/reminder"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// JobKind — ежедневная системная задача рассылки предупреждений (user_id = 0)
+const JobKind = "deadline_alerts"
+
+var (
+	ErrInvalidDay    = errors.New("day must be between 1 and 31")
+	ErrInvalidOffset = errors.New("month_offset must be 0 or -1")
+)
+
+type Store interface {
+	storage.DeadlineStorage
+	GetMonth(ctx context.Context, userID int64, monthTime string) (*domain.CashbackMonth, error)
+}
+
+// Service считает ближайшие сроки выбора категорий по банкам пользователя
+type Service struct {
+	store Store
+	loc   *time.Location
+}
+
+// NewService считает даты по московскому времени — так банки объявляют свои сроки
+func NewService(store Store) *Service {
+	loc, err := time.LoadLocation(reminder.DefaultTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return &Service{store: store, loc: loc}
+}
+
+func Validate(rule domain.DeadlineRule) error {
+	if rule.Day < 1 || rule.Day > 31 {
+		return ErrInvalidDay
+	}
+	if rule.MonthOffset != 0 && rule.MonthOffset != -1 {
+		return ErrInvalidOffset
+	}
+	return nil
+}
+
+// Upcoming возвращает ближайший ещё не прошедший срок по каждому банку пользователя,
+// отсортированный по дате. Filled — категории банка на этот месяц уже сохранены.
+func (s *Service) Upcoming(ctx context.Context, userID int64, now time.Time) ([]domain.Deadline, error) {
+	rules, err := s.store.ListDeadlineRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	today := dateOf(now.In(s.loc))
+	filled := make(map[string]map[string]bool)
+	deadlines := make([]domain.Deadline, 0, len(rules))
+	for _, rule := range rules {
+		month, date := Next(rule, today)
+		banks, ok := filled[month]
+		if !ok {
+			if banks, err = s.filledBanks(ctx, userID, month); err != nil {
+				return nil, err
+			}
+			filled[month] = banks
+		}
+		deadlines = append(deadlines, domain.Deadline{
+			Bank:     rule.Bank,
+			Month:    month,
+			Date:     date.Format("2006-01-02"),
+			DaysLeft: int(date.Sub(today).Hours() / 24),
+			Filled:   banks[rule.Bank],
+		})
+	}
+
+	sort.SliceStable(deadlines, func(i, j int) bool { return deadlines[i].Date < deadlines[j].Date })
+	return deadlines, nil
+}
+
+// Pending — незаполненные банки, срок по которым наступает не позже чем через days дней
+func (s *Service) Pending(ctx context.Context, userID int64, now time.Time, days int) ([]domain.Deadline, error) {
+	deadlines, err := s.Upcoming(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	var pending []domain.Deadline
+	for _, d := range deadlines {
+		if !d.Filled && d.DaysLeft <= days {
+			pending = append(pending, d)
+		}
+	}
+	return pending, nil
+}
+
+func (s *Service) Rules(ctx context.Context, userID int64) ([]domain.DeadlineRule, error) {
+	return s.store.ListDeadlineRules(ctx, userID)
+}
+
+func (s *Service) SetRule(ctx context.Context, userID int64, rule domain.DeadlineRule) error {
+	if err := Validate(rule); err != nil {
+		return err
+	}
+	return s.store.SetDeadlineRule(ctx, userID, rule)
+}
+
+func (s *Service) DeleteRule(ctx context.Context, userID int64, bankName string) error {
+	return s.store.DeleteDeadlineRule(ctx, userID, bankName)
+}
+
+func (s *Service) filledBanks(ctx context.Context, userID int64, month string) (map[string]bool, error) {
+	cashback, err := s.store.GetMonth(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	banks := make(map[string]bool)
+	if cashback != nil {
+		for _, bwc := range cashback.Banks {
+			if len(bwc.Categories) > 0 {
+				banks[bwc.Bank.Name] = true
+			}
+		}
+	}
+	return banks, nil
+}
+
+// Next — месяц, на который ещё можно выбрать категории, и последний день выбора (включительно).
+// Срок «до 31-го» в коротком месяце сдвигается на его последний день.
+func Next(rule domain.DeadlineRule, today time.Time) (string, time.Time) {
+	first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; ; i++ {
+		month := first.AddDate(0, i, 0)
+		deadlineMonth := month.AddDate(0, rule.MonthOffset, 0)
+		day := min(rule.Day, deadlineMonth.AddDate(0, 1, -1).Day())
+		date := time.Date(deadlineMonth.Year(), deadlineMonth.Month(), day, 0, 0, 0, 0, time.UTC)
+		if !date.Before(today) {
+			return month.Format("2006-01"), date
+		}
+	}
+}
+
+// NextRunAt — следующий запуск ежедневной рассылки: 10:00 по времени сервиса
+func (s *Service) NextRunAt(now time.Time) time.Time {
+	local := now.In(s.loc)
+	run := time.Date(local.Year(), local.Month(), local.Day(), 10, 0, 0, 0, s.loc)
+	if !run.After(now) {
+		run = run.AddDate(0, 0, 1)
+	}
+	return run
+}
+
+// dateOf отбрасывает время и пояс, оставляя календарную дату в UTC для подсчёта дней
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ErrorKey — ключ каталога сообщений для ошибки валидации или "", если ошибка не из этого пакета
+func ErrorKey(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidDay):
+		return "deadline.invalid_day"
+	case errors.Is(err, ErrInvalidOffset):
+		return "deadline.invalid_offset"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,201 @@
+// internal/diff/diff.go
+package diff
+
+import (
+	"cashback-tracker/internal/domain"
+	"sort"
+)
+
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionUpdate Action = "update"
+	ActionRemove Action = "remove"
+)
+
+// Change — изменение одной пары банк/категория
+type Change struct {
+	Action     Action   `json:"action"`
+	Bank       string   `json:"bank"`
+	Category   string   `json:"category"`
+	OldPercent *float32 `json:"old_percent,omitempty"`
+	NewPercent *float32 `json:"new_percent,omitempty"`
+}
+
+// Mode — как применяются новые данные: Replace заменяет месяц целиком
+// (всё, чего нет в новых данных, удаляется), Merge только добавляет и обновляет
+type Mode string
+
+const (
+	Replace Mode = "replace"
+	Merge   Mode = "merge"
+)
+
+// Changes сравнивает старые и новые банки; результат отсортирован по банку и категории
+func Changes(oldBanks, newBanks []domain.BankWithCategories, mode Mode) []Change {
+	type key struct{ bank, category string }
+	existing := make(map[key]float32)
+	for _, bwc := range oldBanks {
+		for _, cc := range bwc.Categories {
+			existing[key{bwc.Bank.Name, cc.Category.Name}] = cc.Percent
+		}
+	}
+
+	changes := []Change{}
+	touched := make(map[key]bool)
+	for _, bwc := range newBanks {
+		for _, cc := range bwc.Categories {
+			k := key{bwc.Bank.Name, cc.Category.Name}
+			touched[k] = true
+			newPercent := cc.Percent
+			oldPercent, ok := existing[k]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Action: ActionAdd, Bank: k.bank, Category: k.category, NewPercent: &newPercent})
+			case oldPercent != newPercent:
+				changes = append(changes, Change{Action: ActionUpdate, Bank: k.bank, Category: k.category, OldPercent: &oldPercent, NewPercent: &newPercent})
+			}
+		}
+	}
+
+	if mode == Replace {
+		for k, oldPercent := range existing {
+			if touched[k] {
+				continue
+			}
+			changes = append(changes, Change{Action: ActionRemove, Bank: k.bank, Category: k.category, OldPercent: &oldPercent})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Bank != changes[j].Bank {
+			return changes[i].Bank < changes[j].Bank
+		}
+		return changes[i].Category < changes[j].Category
+	})
+	return changes
+}
+
+// Plan — какие строки будут вставлены, обновлены и удалены при записи
+type Plan struct {
+	Counts   Counts   `json:"counts"`
+	Inserted []Change `json:"inserted"`
+	Updated  []Change `json:"updated"`
+	Deleted  []Change `json:"deleted"`
+}
+
+type Counts struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Deleted  int `json:"deleted"`
+}
+
+// NewPlan раскладывает изменения по действиям
+func NewPlan(changes []Change) Plan {
+	p := Plan{Inserted: []Change{}, Updated: []Change{}, Deleted: []Change{}}
+	for _, ch := range changes {
+		switch ch.Action {
+		case ActionAdd:
+			p.Inserted = append(p.Inserted, ch)
+		case ActionUpdate:
+			p.Updated = append(p.Updated, ch)
+		case ActionRemove:
+			p.Deleted = append(p.Deleted, ch)
+		}
+	}
+	p.Counts = Counts{Inserted: len(p.Inserted), Updated: len(p.Updated), Deleted: len(p.Deleted)}
+	return p
+}
+
+// BankStatus — что произошло с банком между месяцами
+type BankStatus string
+
+const (
+	BankAdded   BankStatus = "added"
+	BankRemoved BankStatus = "removed"
+	BankChanged BankStatus = "changed"
+)
+
+type CategoryPercent struct {
+	Category string  `json:"category"`
+	Percent  float32 `json:"percent"`
+}
+
+type PercentChange struct {
+	Category   string  `json:"category"`
+	OldPercent float32 `json:"old_percent"`
+	NewPercent float32 `json:"new_percent"`
+}
+
+// BankDiff — изменения по одному банку
+type BankDiff struct {
+	Bank              string            `json:"bank"`
+	Status            BankStatus        `json:"status"`
+	CategoriesAdded   []CategoryPercent `json:"categories_added"`
+	CategoriesRemoved []CategoryPercent `json:"categories_removed"`
+	PercentChanged    []PercentChange   `json:"percent_changed"`
+}
+
+// MonthDiff — структурированная разница между двумя месяцами; в Banks только банки с изменениями
+type MonthDiff struct {
+	From         string     `json:"from"`
+	To           string     `json:"to"`
+	BanksAdded   []string   `json:"banks_added"`
+	BanksRemoved []string   `json:"banks_removed"`
+	Banks        []BankDiff `json:"banks"`
+}
+
+func (d MonthDiff) Empty() bool {
+	return len(d.Banks) == 0
+}
+
+// Months сравнивает банки месяца from с банками месяца to
+func Months(from string, oldBanks []domain.BankWithCategories, to string, newBanks []domain.BankWithCategories) MonthDiff {
+	d := MonthDiff{From: from, To: to, BanksAdded: []string{}, BanksRemoved: []string{}, Banks: []BankDiff{}}
+
+	had := bankNames(oldBanks)
+	has := bankNames(newBanks)
+
+	// Changes отсортированы по банку, поэтому изменения одного банка идут подряд
+	for _, ch := range Changes(oldBanks, newBanks, Replace) {
+		if len(d.Banks) == 0 || d.Banks[len(d.Banks)-1].Bank != ch.Bank {
+			status := BankChanged
+			switch {
+			case !had[ch.Bank]:
+				status = BankAdded
+				d.BanksAdded = append(d.BanksAdded, ch.Bank)
+			case !has[ch.Bank]:
+				status = BankRemoved
+				d.BanksRemoved = append(d.BanksRemoved, ch.Bank)
+			}
+			d.Banks = append(d.Banks, BankDiff{
+				Bank:              ch.Bank,
+				Status:            status,
+				CategoriesAdded:   []CategoryPercent{},
+				CategoriesRemoved: []CategoryPercent{},
+				PercentChanged:    []PercentChange{},
+			})
+		}
+		bd := &d.Banks[len(d.Banks)-1]
+		switch ch.Action {
+		case ActionAdd:
+			bd.CategoriesAdded = append(bd.CategoriesAdded, CategoryPercent{ch.Category, *ch.NewPercent})
+		case ActionRemove:
+			bd.CategoriesRemoved = append(bd.CategoriesRemoved, CategoryPercent{ch.Category, *ch.OldPercent})
+		case ActionUpdate:
+			bd.PercentChanged = append(bd.PercentChanged, PercentChange{ch.Category, *ch.OldPercent, *ch.NewPercent})
+		}
+	}
+	return d
+}
+
+func bankNames(banks []domain.BankWithCategories) map[string]bool {
+	names := make(map[string]bool, len(banks))
+	for _, bwc := range banks {
+		if len(bwc.Categories) > 0 {
+			names[bwc.Bank.Name] = true
+		}
+	}
+	return names
+}
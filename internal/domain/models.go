@@ -1,6 +1,11 @@
 // internal/domain/models.go
 package domain
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type Bank struct {
 	ID   int    `json:"-"`
 	Name string `json:"name"`
@@ -26,4 +31,209 @@ type CashbackMonth struct {
 	Month  string               `json:"month"`
 	UserID int64                  `json:"-"`
 	Banks  []BankWithCategories `json:"banks"`
-}
\ No newline at end of file
+	// Version растёт при каждом изменении месяца (не обязательно на 1) и никогда
+	// не повторяется, даже после удаления месяца; 0 — месяца нет
+	Version int `json:"version"`
+}
+
+// MonthWrite — месяц до и после записи, прочитанные той же транзакцией, что и
+// сама запись. Before = nil — месяца не было; After.Version — новая версия.
+type MonthWrite struct {
+	Before *CashbackMonth
+	After  *CashbackMonth
+}
+
+// CashbackRow — плоская строка выгрузки: месяц, банк, категория, процент
+type CashbackRow struct {
+	Month    string  `json:"month"`
+	Bank     string  `json:"bank"`
+	Category string  `json:"category"`
+	Percent  float32 `json:"percent"`
+}
+
+// DeadLetter — исходящее сообщение Telegram, которое не удалось доставить после всех повторов
+type DeadLetter struct {
+	ID        int64           `json:"id"`
+	ChatID    int64           `json:"chat_id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	Error     string          `json:"error"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ReminderSettings — когда напоминать о выборе категорий на следующий месяц
+type ReminderSettings struct {
+	UserID   int64  `json:"-"`
+	Day      int    `json:"day"`
+	Time     string `json:"time"`
+	Timezone string `json:"timezone"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// Job — отложенная задача планировщика; у пользователя не больше одной задачи каждого вида
+type Job struct {
+	ID       int64
+	Kind     string
+	UserID   int64
+	RunAt    time.Time
+	Attempts int
+}
+
+// DeadlineRule — до какого числа банк принимает выбор категорий на месяц.
+// MonthOffset 0 — срок в том же месяце, -1 — в предыдущем.
+type DeadlineRule struct {
+	Bank        string `json:"bank"`
+	Day         int    `json:"day"`
+	MonthOffset int    `json:"month_offset"`
+}
+
+// Deadline — ближайший срок выбора категорий банка
+type Deadline struct {
+	Bank     string `json:"bank"`
+	Month    string `json:"month"`
+	Date     string `json:"date"`
+	DaysLeft int    `json:"days_left"`
+	Filled   bool   `json:"filled"`
+}
+
+// Purchase — покупка, по которой начисляется кэшбэк
+type Purchase struct {
+	Date     string  `json:"date"`
+	Bank     string  `json:"bank"`
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+}
+
+// PurchaseTotal — сумма покупок за месяц по банку и категории
+type PurchaseTotal struct {
+	Bank     string  `json:"bank"`
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+}
+
+// CategoryBanks — категория и банки, которые дают по ней кэшбэк, от большего процента к меньшему
+type CategoryBanks struct {
+	Category string        `json:"category"`
+	Banks    []BankPercent `json:"banks"`
+}
+
+type BankPercent struct {
+	Bank    string  `json:"bank"`
+	Percent float32 `json:"percent"`
+}
+
+// HistoryFilter — условия поиска по истории; пустые поля не ограничивают выборку
+type HistoryFilter struct {
+	Category   string
+	Bank       string
+	From       string
+	To         string
+	MinPercent float32
+}
+
+// NameKind — что подсказываем: банки или категории
+type NameKind string
+
+const (
+	NameBank     NameKind = "bank"
+	NameCategory NameKind = "category"
+)
+
+// IdempotencyRecord — сохранённый ответ на запрос с Idempotency-Key.
+// Запись уникальна для (UserID, Key, Route); Status = 0 — запрос ещё выполняется.
+type IdempotencyRecord struct {
+	UserID      int64
+	Key         string
+	Route       string
+	RequestHash string
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// EventType — что изменилось в данных пользователя
+type EventType string
+
+const (
+	EventMonthSaved      EventType = "month.saved"      // месяц заменён целиком
+	EventMonthPatched    EventType = "month.patched"    // в месяц дописаны банки или категории
+	EventMonthDeleted    EventType = "month.deleted"    // месяц удалён
+	EventBankUpdated     EventType = "bank.updated"     // заменены категории банка
+	EventBankDeleted     EventType = "bank.deleted"     // банк убран из месяца
+	EventCategoryDeleted EventType = "category.deleted" // категория убрана у банка
+)
+
+// Event — изменение месяца пользователя. ID — номер записи в outbox, он растёт монотонно
+// и служит Last-Event-ID; Version — версия месяца после изменения (0 — месяц удалён).
+type Event struct {
+	ID       int64     `json:"id"`
+	Type     EventType `json:"type"`
+	UserID   int64     `json:"-"`
+	Month    string    `json:"month"`
+	Bank     string    `json:"bank,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Version  int       `json:"version"`
+	At       time.Time `json:"at"`
+}
+
+// EventTypes — все виды событий, на которые можно подписать webhook
+var EventTypes = []EventType{
+	EventMonthSaved, EventMonthPatched, EventMonthDeleted,
+	EventBankUpdated, EventBankDeleted, EventCategoryDeleted,
+}
+
+// Webhook — адрес пользователя для событий. Events — фильтр, пустой — все события.
+// Secret подписывает тела запросов и показывается только при создании.
+type Webhook struct {
+	ID        int64       `json:"id"`
+	UserID    int64       `json:"-"`
+	URL       string      `json:"url"`
+	Secret    string      `json:"secret,omitempty"`
+	Events    []EventType `json:"events"`
+	Enabled   bool        `json:"enabled"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Matches — подписан ли webhook на событие этого вида
+func (w Webhook) Matches(t EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // ждёт первой попытки или повтора
+	DeliveryDelivered DeliveryStatus = "delivered" // получатель ответил 2xx
+	DeliveryFailed    DeliveryStatus = "failed"    // попытки исчерпаны
+)
+
+// WebhookDelivery — доставка события на webhook. LastStatus — HTTP-статус последней
+// попытки (0 — ответа не было), LastError — почему она не удалась.
+type WebhookDelivery struct {
+	ID            int64          `json:"id"`
+	WebhookID     int64          `json:"webhook_id"`
+	EventID       int64          `json:"event_id"`
+	EventType     EventType      `json:"event_type"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	LastStatus    int            `json:"last_status,omitempty"`
+	LastError     string         `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	DeliveredAt   *time.Time     `json:"delivered_at,omitempty"`
+
+	// заполняются при выборке на отправку
+	URL     string `json:"-"`
+	Secret  string `json:"-"`
+	Payload []byte `json:"-"`
+}
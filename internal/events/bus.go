@@ -0,0 +1,147 @@
+// internal/events/bus.go
+package events
+
+import (
+	"cashback-tracker/internal/domain"
+	"sync"
+)
+
+// subscriberBuffer — сколько событий ждёт медленного подписчика; при переполнении
+// подписка закрывается, и клиент докачивает пропущенное по Last-Event-ID
+const subscriberBuffer = 64
+
+// Bus раздаёт события изменений подписчикам того же пользователя и помнит последние
+// historySize событий, чтобы переподключившийся клиент получил пропущенное.
+// События приходят из outbox (outbox.Relay), их ID — номера строк outbox: растут
+// монотонно и не повторяются после перезапуска.
+type Bus struct {
+	mu          sync.Mutex
+	history     []domain.Event
+	historySize int
+	// lastID — последнее опубликованное событие; floor — ID, после которого история
+	// полна: раньше него события вытеснены или опубликованы до запуска процесса
+	lastID  int64
+	floor   int64
+	started bool
+	subs    map[int64]map[*Subscription]struct{}
+	closed  bool
+}
+
+// Subscription — поток событий одного пользователя. C закрывается, когда подписчик
+// не успевает читать или шина остановлена.
+type Subscription struct {
+	C      <-chan domain.Event
+	ch     chan domain.Event
+	userID int64
+}
+
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		historySize: historySize,
+		subs:        make(map[int64]map[*Subscription]struct{}),
+	}
+}
+
+// Publish запоминает событие и рассылает его подписчикам пользователя. Повтор уже
+// опубликованного события (outbox доставляет «хотя бы раз») пропускается; событие,
+// опубликованное повторно после сбоя, может прийти позже более новых.
+func (b *Bus) Publish(e domain.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed || b.seen(e.ID) {
+		return
+	}
+	if !b.started {
+		b.started = true
+		b.floor = e.ID - 1
+	}
+	b.lastID = max(b.lastID, e.ID)
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		evicted := len(b.history) - b.historySize
+		b.floor = b.history[evicted-1].ID
+		b.history = b.history[evicted:]
+	}
+
+	for sub := range b.subs[e.UserID] {
+		select {
+		case sub.ch <- e:
+		default:
+			b.drop(sub)
+		}
+	}
+}
+
+func (b *Bus) seen(id int64) bool {
+	for _, e := range b.history {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe подписывает на события пользователя. Если lastID > 0, сначала возвращаются
+// события после него. complete = false — часть событий после lastID уже не восстановить
+// (вытеснены из истории или опубликованы до запуска), и клиенту нужно перечитать данные целиком.
+func (b *Bus) Subscribe(userID, lastID int64) (sub *Subscription, missed []domain.Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan domain.Event, subscriberBuffer)
+	sub = &Subscription{C: ch, ch: ch, userID: userID}
+	if b.closed {
+		close(ch)
+		return sub, nil, true
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+
+	if lastID <= 0 {
+		return sub, nil, true
+	}
+	complete = b.started && lastID >= b.floor && lastID <= b.lastID
+	for _, e := range b.history {
+		if e.ID > lastID && e.UserID == userID {
+			missed = append(missed, e)
+		}
+	}
+	return sub, missed, complete
+}
+
+// Unsubscribe отписывает; повторный вызов ничего не делает
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.drop(sub)
+}
+
+// Close закрывает все подписки, чтобы открытые потоки завершились при остановке сервера
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.drop(sub)
+		}
+	}
+}
+
+func (b *Bus) drop(sub *Subscription) {
+	subs, ok := b.subs[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.userID)
+	}
+	close(sub.ch)
+}
@@ -0,0 +1,179 @@
+// internal/export/export.go
+package export
+
+import (
+	"archive/zip"
+	"cashback-tracker/internal/domain"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+	FormatJSON Format = "json"
+)
+
+// Header — заголовок таблицы выгрузки, порядок колонок общий для всех форматов
+var Header = []string{"month", "bank", "category", "percent"}
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatXLSX, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q, expected csv, xlsx or json", s)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatJSON:
+		return "application/json"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// FileName — имя файла вида cashback_2025-01_2025-12.csv
+func (f Format) FileName(from, to string) string {
+	return fmt.Sprintf("cashback_%s_%s.%s", from, to, f)
+}
+
+func Write(w io.Writer, f Format, rows []domain.CashbackRow) error {
+	switch f {
+	case FormatCSV:
+		return writeCSV(w, rows)
+	case FormatXLSX:
+		return writeXLSX(w, rows)
+	case FormatJSON:
+		return writeJSON(w, rows)
+	default:
+		return fmt.Errorf("unsupported format %q", f)
+	}
+}
+
+func formatPercent(p float32) string {
+	return strconv.FormatFloat(float64(p), 'f', -1, 32)
+}
+
+func writeCSV(w io.Writer, rows []domain.CashbackRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Header); err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+	for _, r := range rows {
+		if err := cw.Write([]string{r.Month, r.Bank, r.Category, formatPercent(r.Percent)}); err != nil {
+			return fmt.Errorf("write csv row: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, rows []domain.CashbackRow) error {
+	if rows == nil {
+		rows = []domain.CashbackRow{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+// === XLSX ===
+// Минимальная книга из одного листа: строки пишутся как inline strings,
+// проценты — числами, так что внешних зависимостей не нужно.
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Cashback" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+func writeXLSX(w io.Writer, rows []domain.CashbackRow) error {
+	zw := zip.NewWriter(w)
+
+	static := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, f := range static {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", f.name, err)
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return fmt.Errorf("write %s: %w", f.name, err)
+		}
+	}
+
+	fw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return fmt.Errorf("create sheet: %w", err)
+	}
+	if err := writeSheet(fw, rows); err != nil {
+		return fmt.Errorf("write sheet: %w", err)
+	}
+
+	return zw.Close()
+}
+
+func writeSheet(w io.Writer, rows []domain.CashbackRow) error {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeRow := func(n int, cells []string, numericLast bool) {
+		fmt.Fprintf(&sb, `<row r="%d">`, n)
+		for i, v := range cells {
+			ref := fmt.Sprintf("%c%d", 'A'+i, n)
+			if numericLast && i == len(cells)-1 {
+				fmt.Fprintf(&sb, `<c r="%s"><v>%s</v></c>`, ref, v)
+				continue
+			}
+			fmt.Fprintf(&sb, `<c r="%s" t="inlineStr"><is><t>`, ref)
+			xml.EscapeText(&sb, []byte(v))
+			sb.WriteString(`</t></is></c>`)
+		}
+		sb.WriteString(`</row>`)
+	}
+
+	writeRow(1, Header, false)
+	for i, r := range rows {
+		writeRow(i+2, []string{r.Month, r.Bank, r.Category, formatPercent(r.Percent)}, true)
+	}
+
+	sb.WriteString(`</sheetData></worksheet>`)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,360 @@
+// internal/export/import.go
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"cashback-tracker/internal/diff"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/storage"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Mode string
+
+const (
+	// ModeMerge добавляет и обновляет категории, ничего не удаляя (PatchMonth)
+	ModeMerge Mode = "merge"
+	// ModeReplace полностью перезаписывает месяц содержимым файла (SaveMonth)
+	ModeReplace Mode = "replace"
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return ModeMerge, nil
+	case ModeMerge, ModeReplace:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unsupported mode %q, expected merge or replace", s)
+	}
+}
+
+// RowError — ошибка валидации конкретной строки файла (строки нумеруются с 1, включая заголовок).
+// Code — стабильный код ошибки, Message заполняет Localize на языке пользователя.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	args []any
+}
+
+func rowError(row int, field, code string, args ...any) RowError {
+	return RowError{Row: row, Field: field, Code: code, args: args}
+}
+
+// Text — описание ошибки на языке lang (ключ каталога — "import." + Code)
+func (e RowError) Text(lang i18n.Lang) string {
+	return i18n.T(lang, "import."+e.Code, e.args...)
+}
+
+// Read разбирает файл в формате выгрузки и валидирует каждую строку.
+// Ошибка возвращается только если файл не удалось прочитать целиком,
+// проблемы отдельных строк попадают в []RowError.
+func Read(r io.Reader, f Format) ([]domain.CashbackRow, []RowError, error) {
+	var raw []rawRow
+	var err error
+	switch f {
+	case FormatCSV:
+		raw, err = readCSV(r)
+	case FormatJSON:
+		raw, err = readJSON(r)
+	default:
+		return nil, nil, fmt.Errorf("import from %q is not supported, use csv or json", f)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, rowErrs := validateRows(raw)
+	return rows, rowErrs, nil
+}
+
+type rawRow struct {
+	line     int
+	columns  int
+	month    string
+	bank     string
+	category string
+	percent  string
+}
+
+func readCSV(r io.Reader) ([]rawRow, error) {
+	br := bufio.NewReader(r)
+	first, _ := br.Peek(4096)
+	if i := bytes.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	// Excel в русской локали сохраняет CSV через точку с запятой
+	if bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		cr.Comma = ';'
+	}
+
+	var rows []rawRow
+	line := 0
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("read csv line %d: %w", line, err)
+		}
+		if line == 1 && isHeader(record) {
+			continue
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		row := rawRow{line: line, columns: len(record)}
+		if len(record) == len(Header) {
+			row.month, row.bank, row.category, row.percent = record[0], record[1], record[2], record[3]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func isHeader(record []string) bool {
+	if len(record) != len(Header) {
+		return false
+	}
+	for i, h := range Header {
+		if !strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(record[i], "\ufeff")), h) {
+			return false
+		}
+	}
+	return true
+}
+
+func readJSON(r io.Reader) ([]rawRow, error) {
+	var items []struct {
+		Month    string          `json:"month"`
+		Bank     string          `json:"bank"`
+		Category string          `json:"category"`
+		Percent  json.RawMessage `json:"percent"`
+	}
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	rows := make([]rawRow, len(items))
+	for i, it := range items {
+		rows[i] = rawRow{
+			line:     i + 1,
+			columns:  len(Header),
+			month:    it.Month,
+			bank:     it.Bank,
+			category: it.Category,
+			percent:  strings.Trim(string(it.Percent), `"`),
+		}
+	}
+	return rows, nil
+}
+
+func validateRows(raw []rawRow) ([]domain.CashbackRow, []RowError) {
+	var rows []domain.CashbackRow
+	var rowErrs []RowError
+	seen := make(map[string]int)
+
+	for _, r := range raw {
+		if r.columns != len(Header) {
+			rowErrs = append(rowErrs, rowError(r.line, "", "column_count", len(Header), r.columns))
+			continue
+		}
+
+		var errs []RowError
+		month := strings.TrimSpace(r.month)
+		if _, err := time.Parse("2006-01", month); err != nil {
+			errs = append(errs, rowError(r.line, "month", "month_invalid", r.month))
+		}
+		bank := strings.Join(strings.Fields(r.bank), " ")
+		if bank == "" {
+			errs = append(errs, rowError(r.line, "bank", "bank_blank"))
+		}
+		category := strings.Join(strings.Fields(r.category), " ")
+		if category == "" {
+			errs = append(errs, rowError(r.line, "category", "category_blank"))
+		}
+		percent, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(r.percent), ",", ".", 1), 32)
+		if err != nil {
+			errs = append(errs, rowError(r.line, "percent", "percent_invalid", r.percent))
+		} else if percent < 0 || percent > 100 {
+			errs = append(errs, rowError(r.line, "percent", "percent_range", percent))
+		}
+
+		if len(errs) == 0 {
+			key := month + "\x00" + strings.ToLower(bank) + "\x00" + strings.ToLower(category)
+			if prev, dup := seen[key]; dup {
+				errs = append(errs, rowError(r.line, "", "duplicate", prev))
+			} else {
+				seen[key] = r.line
+			}
+		}
+
+		if len(errs) > 0 {
+			rowErrs = append(rowErrs, errs...)
+			continue
+		}
+		rows = append(rows, domain.CashbackRow{
+			Month:    month,
+			Bank:     bank,
+			Category: category,
+			Percent:  float32(percent),
+		})
+	}
+	return rows, rowErrs
+}
+
+// Group собирает плоские строки в месяцы, отсортированные по возрастанию
+func Group(rows []domain.CashbackRow) []domain.CashbackMonth {
+	byMonth := make(map[string]*domain.CashbackMonth)
+	bankIdx := make(map[string]map[string]int)
+	var months []string
+
+	for _, r := range rows {
+		m, ok := byMonth[r.Month]
+		if !ok {
+			m = &domain.CashbackMonth{Month: r.Month}
+			byMonth[r.Month] = m
+			bankIdx[r.Month] = make(map[string]int)
+			months = append(months, r.Month)
+		}
+		i, ok := bankIdx[r.Month][r.Bank]
+		if !ok {
+			i = len(m.Banks)
+			bankIdx[r.Month][r.Bank] = i
+			m.Banks = append(m.Banks, domain.BankWithCategories{Bank: domain.Bank{Name: r.Bank}})
+		}
+		m.Banks[i].Categories = append(m.Banks[i].Categories, domain.CashbackCategory{
+			Category: domain.Category{Name: r.Category},
+			Percent:  r.Percent,
+		})
+	}
+
+	sort.Strings(months)
+	result := make([]domain.CashbackMonth, len(months))
+	for i, month := range months {
+		result[i] = *byMonth[month]
+	}
+	return result
+}
+
+// === Import ===
+
+type MonthResult struct {
+	Month   string        `json:"month"`
+	Changes []diff.Change `json:"changes"`
+	Applied bool          `json:"applied"`
+	Error   string        `json:"error,omitempty"`
+
+	err error
+}
+
+type ImportResult struct {
+	DryRun bool          `json:"dry_run"`
+	Mode   Mode          `json:"mode"`
+	Rows   int           `json:"rows"`
+	Errors []RowError    `json:"errors,omitempty"`
+	Months []MonthResult `json:"months"`
+}
+
+// Localize переводит на язык lang ошибки строк и месяцев, которые не удалось записать
+func (r *ImportResult) Localize(lang i18n.Lang) {
+	for i := range r.Errors {
+		r.Errors[i].Message = r.Errors[i].Text(lang)
+	}
+	for i := range r.Months {
+		if r.Months[i].err == nil {
+			continue
+		}
+		var invalid *storage.ValidationError
+		if errors.As(r.Months[i].err, &invalid) {
+			r.Months[i].Error = invalid.Text(lang)
+		} else {
+			r.Months[i].Error = i18n.T(lang, "import.month_failed")
+		}
+	}
+}
+
+// ErrInvalidRows возвращается Import, если в файле есть невалидные строки —
+// в этом случае ничего не применяется, а детали лежат в ImportResult.Errors
+var ErrInvalidRows = errors.New("import contains invalid rows")
+
+type Store interface {
+	GetMonth(ctx context.Context, userID int64, monthTime string) (*domain.CashbackMonth, error)
+	SaveMonth(ctx context.Context, userID int64, monthTime string, bankCategories []domain.BankWithCategories) (*domain.MonthWrite, error)
+	PatchMonth(ctx context.Context, userID int64, monthTime string, bankCategories []domain.BankWithCategories) (*domain.MonthWrite, error)
+}
+
+// Import считает изменения по каждому месяцу и, если это не dry-run, применяет их.
+// Каждый месяц пишется своей транзакцией через SaveMonth/PatchMonth, поэтому
+// месяц либо применяется целиком, либо не применяется вовсе.
+func Import(ctx context.Context, store Store, userID int64, rows []domain.CashbackRow, rowErrs []RowError, mode Mode, dryRun bool) (*ImportResult, error) {
+	result := &ImportResult{
+		DryRun: dryRun,
+		Mode:   mode,
+		Rows:   len(rows) + len(rowErrs),
+		Errors: rowErrs,
+		Months: []MonthResult{},
+	}
+
+	months := Group(rows)
+	for _, month := range months {
+		current, err := store.GetMonth(ctx, userID, month.Month)
+		if err != nil {
+			return nil, fmt.Errorf("load month %s: %w", month.Month, err)
+		}
+		var currentBanks []domain.BankWithCategories
+		if current != nil {
+			currentBanks = current.Banks
+		}
+		result.Months = append(result.Months, MonthResult{
+			Month:   month.Month,
+			Changes: diff.Changes(currentBanks, month.Banks, diff.Mode(mode)),
+		})
+	}
+
+	if len(rowErrs) > 0 {
+		return result, ErrInvalidRows
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	for i, month := range months {
+		if len(result.Months[i].Changes) == 0 {
+			continue
+		}
+		var err error
+		if mode == ModeReplace {
+			_, err = store.SaveMonth(ctx, userID, month.Month, month.Banks)
+		} else {
+			_, err = store.PatchMonth(ctx, userID, month.Month, month.Banks)
+		}
+		if err != nil {
+			result.Months[i].Error, result.Months[i].err = err.Error(), err
+			continue
+		}
+		result.Months[i].Applied = true
+	}
+	return result, nil
+}
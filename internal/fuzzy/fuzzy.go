@@ -0,0 +1,108 @@
+// internal/fuzzy/fuzzy.go
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+)
+
+// MinScore — ниже этого сходства кандидат подсказкой не считается
+const MinScore = 0.6
+
+// ConfidentScore — с этого сходства (вхождение и выше) кандидат считается
+// тем, что искали, а не подсказкой
+const ConfidentScore = 0.8
+
+// Normalize приводит название к виду для сравнения: нижний регистр, ё → е, без лишних пробелов
+func Normalize(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	return strings.ReplaceAll(s, "ё", "е")
+}
+
+// Score — сходство query и candidate от 0 до 1: точное совпадение, затем начало
+// названия, вхождение и, наконец, расстояние Левенштейна
+func Score(query, candidate string) float64 {
+	q, c := Normalize(query), Normalize(candidate)
+	switch {
+	case q == "" || c == "":
+		return 0
+	case q == c:
+		return 1
+	case strings.HasPrefix(c, q):
+		return 0.9
+	case strings.Contains(c, q):
+		return 0.8
+	}
+	qr, cr := []rune(q), []rune(c)
+	return 1 - float64(Levenshtein(qr, cr))/float64(max(len(qr), len(cr)))
+}
+
+// Rank возвращает до limit кандидатов с Score не ниже MinScore, от лучших к худшим
+func Rank(query string, candidates []string, limit int) []string {
+	type scored struct {
+		name  string
+		score float64
+	}
+	var matches []scored
+	seen := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		if score := Score(query, c); score >= MinScore {
+			matches = append(matches, scored{c, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	result := make([]string, 0, min(limit, len(matches)))
+	for i := 0; i < len(matches) && i < limit; i++ {
+		result = append(result, matches[i].name)
+	}
+	return result
+}
+
+// Best возвращает единственного кандидата с наибольшим Score не ниже
+// ConfidentScore. Если лучших несколько с одинаковым сходством, запрос
+// неоднозначен и ok = false
+func Best(query string, candidates []string) (best string, ok bool) {
+	top := 0.0
+	for _, c := range candidates {
+		score := Score(query, c)
+		switch {
+		case score < ConfidentScore || score < top:
+		case score > top:
+			best, top, ok = c, score, true
+		case Normalize(c) != Normalize(best):
+			ok = false
+		}
+	}
+	return best, ok
+}
+
+// Levenshtein — число вставок, удалений и замен символов, превращающих a в b
+func Levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,204 @@
+// internal/handler/batch.go
+package handler
+
+import (
+	"cashback-tracker/internal/batch"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchRequest — упорядоченный список операций, которые выполняются вместе или не выполняются вовсе.
+// Не больше batch.MaxOperations операций — проверяется в Batch, чтобы лимит жил в одном месте.
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,dive"`
+}
+
+// BatchOperation — одна операция пакета. Нужные поля зависят от op:
+// save_month и patch_month — banks; update_bank — bank и categories;
+// delete_bank — bank; delete_category — bank и category; delete_month — только month.
+// if_match — версия месяца (ETag без кавычек), как заголовок If-Match у одиночного запроса.
+type BatchOperation struct {
+	Op         string          `json:"op" validate:"required,oneof=save_month patch_month update_bank delete_bank delete_category delete_month"`
+	Month      string          `json:"month" validate:"required,yearmonth"`
+	Bank       string          `json:"bank"`
+	Category   string          `json:"category"`
+	Banks      []BatchBank     `json:"banks" validate:"omitempty,dive"`
+	Categories []BatchCategory `json:"categories" validate:"omitempty,dive"`
+	IfMatch    *int            `json:"if_match" validate:"omitempty,gte=0"`
+}
+
+// BatchResponse — итог пакета; с dry_run всё выполнено и откачено, Applied = false
+type BatchResponse struct {
+	Applied bool           `json:"applied"`
+	DryRun  bool           `json:"dry_run"`
+	Results []batch.Result `json:"results"`
+}
+
+type BatchBank struct {
+	Name       string          `json:"name" validate:"required,notblank"`
+	Categories []BatchCategory `json:"categories" validate:"required,min=1,dive"`
+}
+
+type BatchCategory struct {
+	Name    string  `json:"name" validate:"required,notblank"`
+	Percent float32 `json:"percent" validate:"required,gte=0,lte=100"`
+}
+
+// Batch godoc
+// @Summary Run several writes atomically
+// @Description Runs an ordered list of operations across months in one database transaction.
+// @Description Either every operation is applied and per-operation results are returned,
+// @Description or the first failing operation is reported and nothing is changed.
+// @Tags cashback
+// @Accept json
+// @Produce json
+// @Param request body BatchRequest true "Operations"
+// @Param dry_run query bool false "Run the batch and roll it back, only reporting whether it would succeed"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 412 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/batch [post]
+func (h *CashbackHandler) Batch(c *gin.Context) {
+	lang := middleware.Lang(c)
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if len(req.Operations) > batch.MaxOperations {
+		problem.Invalid(c, lang, []problem.FieldError{{
+			Field:   "operations",
+			Code:    "max",
+			Message: i18n.T(lang, "validation.max_items", "operations", batch.MaxOperations),
+		}})
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+	if fields := batchFieldErrors(lang, req.Operations); len(fields) > 0 {
+		problem.Invalid(c, lang, fields)
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	ops := make([]batch.Operation, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = batch.Operation{
+			Kind:       batch.Kind(op.Op),
+			Month:      op.Month,
+			Bank:       op.Bank,
+			Category:   op.Category,
+			Banks:      batchBanks(op.Banks),
+			Categories: batchCategories(op.Categories),
+			Version:    op.IfMatch,
+		}
+	}
+
+	dryRun := isDryRun(c)
+	results, err := batch.Run(context.Background(), h.store, userID, ops, dryRun)
+	if err != nil {
+		slog.Error("Batch failed", "error", err, "user_id", userID, "operations", len(ops))
+		batchFailed(c, lang, err)
+		return
+	}
+
+	slog.Info("Batch applied", "user_id", userID, "operations", len(ops), "dry_run", dryRun)
+	c.JSON(http.StatusOK, BatchResponse{Applied: !dryRun, DryRun: dryRun, Results: results})
+}
+
+// batchFieldErrors проверяет поля, обязательность которых зависит от вида операции
+func batchFieldErrors(lang i18n.Lang, ops []BatchOperation) []problem.FieldError {
+	var fields []problem.FieldError
+	require := func(i int, name string, present bool) {
+		if present {
+			return
+		}
+		fields = append(fields, problem.FieldError{
+			Field:   fmt.Sprintf("operations[%d].%s", i, name),
+			Code:    "required",
+			Message: i18n.T(lang, "validation.required", name),
+		})
+	}
+	for i, op := range ops {
+		switch batch.Kind(op.Op) {
+		case batch.SaveMonth, batch.PatchMonth:
+			require(i, "banks", len(op.Banks) > 0)
+		case batch.UpdateBank:
+			require(i, "bank", op.Bank != "")
+			require(i, "categories", len(op.Categories) > 0)
+		case batch.DeleteBank:
+			require(i, "bank", op.Bank != "")
+		case batch.DeleteCategory:
+			require(i, "bank", op.Bank != "")
+			require(i, "category", op.Category != "")
+		}
+	}
+	return fields
+}
+
+// batchFailed отвечает на ошибку пакета: статус — как у одиночного запроса с той же
+// ошибкой, в errors — номер операции, на которой пакет остановился
+func batchFailed(c *gin.Context, lang i18n.Lang, err error) {
+	var opErr *batch.OpError
+	if !errors.As(err, &opErr) {
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.batch_failed_internal")
+		return
+	}
+	status, key, args := storageError(lang, opErr.Err, "api.internal")
+	reason := i18n.T(lang, key, args...)
+	problem.Write(c, problem.Problem{
+		Status: status,
+		Code:   problem.Code("api.batch_failed"),
+		Detail: i18n.T(lang, "api.batch_failed", opErr.Index, opErr.Op, reason),
+		Errors: []problem.FieldError{{
+			Field:   fmt.Sprintf("operations[%d]", opErr.Index),
+			Code:    problem.Code(key),
+			Message: reason,
+		}},
+	})
+}
+
+func batchBanks(reqs []BatchBank) []domain.BankWithCategories {
+	if len(reqs) == 0 {
+		return nil
+	}
+	banks := make([]domain.BankWithCategories, len(reqs))
+	for i, b := range reqs {
+		banks[i] = domain.BankWithCategories{
+			Bank:       domain.Bank{Name: b.Name},
+			Categories: batchCategories(b.Categories),
+		}
+	}
+	return banks
+}
+
+func batchCategories(reqs []BatchCategory) []domain.CashbackCategory {
+	if len(reqs) == 0 {
+		return nil
+	}
+	categories := make([]domain.CashbackCategory, len(reqs))
+	for i, cat := range reqs {
+		categories[i] = domain.CashbackCategory{
+			Category: domain.Category{Name: cat.Name},
+			Percent:  cat.Percent,
+		}
+	}
+	return categories
+}
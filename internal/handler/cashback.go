@@ -2,13 +2,25 @@
 package handler
 
 import (
+	"bytes"
+	"cashback-tracker/internal/diff"
 	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/export"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
 	"cashback-tracker/internal/storage"
+	"cashback-tracker/internal/summary"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	val "cashback-tracker/internal/validator"
 
@@ -20,6 +32,8 @@ type CombinedStorage interface {
 	storage.CashbackStorage
 	storage.BankStorage
 	storage.CategoryStorage
+	storage.NameSearchStorage
+	storage.TxStorage
 }
 
 type CashbackHandler struct {
@@ -37,31 +51,29 @@ func NewCashbackHandler(store CombinedStorage) *CashbackHandler {
 // @Accept json
 // @Produce json
 // @Param request body SaveMonthRequest true "Month data"
+// @Param dry_run query bool false "Only return the rows that would be inserted, updated and deleted"
 // @Success 200 {object} map[string]string{"status":"ok"}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
 // @Router /api/v1/month [post]
+// @Router /api/v1/month [put]
 func (h *CashbackHandler) SaveMonth(c *gin.Context) {
+	lang := middleware.Lang(c)
 	slog.Info("SaveMonth request received")
 	var req SaveMonthRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
 		return
 	}
 
-	if err := validateStruct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validRequest(c, lang, req) {
 		return
 	}
 
-	userIDVal, ok := c.Get("user_id")
+	userID, ok := userIDFrom(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "user_id missing"})
-		return
-	}
-	userID, ok := userIDVal.(int64)
-	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id"})
 		return
 	}
 
@@ -81,9 +93,25 @@ func (h *CashbackHandler) SaveMonth(c *gin.Context) {
 		}
 	}
 
-	if err := h.store.SaveMonth(context.Background(), userID, req.Month, bankCategories); err != nil {
+	// dry_run: месяц заменяется целиком, поэтому всё, чего нет в запросе, попадёт в deleted
+	if isDryRun(c) {
+		current, err := h.currentBanks(context.Background(), userID, req.Month)
+		if err != nil {
+			slog.Error("SaveMonth dry run failed", "error", err, "user_id", userID, "month", req.Month)
+			problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+			return
+		}
+		writePlan(c, req.Month, diff.Changes(current, bankCategories, diff.Replace))
+		return
+	}
+
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+	if _, err := h.store.SaveMonth(ctx, userID, req.Month, bankCategories); err != nil {
 		slog.Error("Failed to save month", "error", err, "user_id", userID, "month", req.Month)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save month"})
+		storageFailed(c, lang, err, "api.save_failed")
 		return
 	}
 
@@ -95,34 +123,37 @@ func (h *CashbackHandler) SaveMonth(c *gin.Context) {
 // @Summary Get cashback for a month
 // @Param month query string true "Month in YYYY-MM format"
 // @Success 200 {object} domain.CashbackMonth
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Header 200 {string} ETag "Month version"
 // @Router /api/v1/month [get]
 func (h *CashbackHandler) GetMonth(c *gin.Context) {
+	lang := middleware.Lang(c)
 	month := c.Query("month")
 	if month == "" || len(month) != 7 || month[4] != '-' {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month query param required in YYYY-MM format"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_required")
 		return
 	}
 
-	userIDVal, ok := c.Get("user_id")
-	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "user_id missing"})
-		return
-	}
-	userID, ok := userIDVal.(int64)
+	userID, ok := userIDFrom(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id"})
 		return
 	}
 
 	result, err := h.store.GetMonth(context.Background(), userID, month)
 	if err != nil {
 		slog.Error("GetMonth failed", "error", err, "user_id", userID, "month", month)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
 		return
 	}
 	if result == nil {
-		c.JSON(http.StatusOK, domain.CashbackMonth{Month: month, Banks: []domain.BankWithCategories{}})
+		result = &domain.CashbackMonth{Month: month, Banks: []domain.BankWithCategories{}}
+	}
+
+	// ETag — версия месяца; её же клиент присылает в If-Match при записи
+	tag := etag(result.Version)
+	c.Header("ETag", tag)
+	if c.GetHeader("If-None-Match") == tag {
+		c.Status(http.StatusNotModified)
 		return
 	}
 	c.JSON(http.StatusOK, result)
@@ -133,35 +164,30 @@ func (h *CashbackHandler) GetMonth(c *gin.Context) {
 // @Param month query string true "Month in YYYY-MM format"
 // @Param q query string true "Category name"
 // @Success 200 {array} domain.Bank
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} problem.Problem
 // @Router /api/v1/search/category [get]
 func (h *CashbackHandler) SearchByCategory(c *gin.Context) {
+	lang := middleware.Lang(c)
 	month := c.Query("month")
 	query := c.Query("q")
 	if month == "" || query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month and q query params required"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_q_required")
 		return
 	}
 	if len(month) != 7 || month[4] != '-' {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month must be in YYYY-MM format"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_format")
 		return
 	}
 
-	userIDVal, ok := c.Get("user_id")
+	userID, ok := userIDFrom(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "user_id missing"})
-		return
-	}
-	userID, ok := userIDVal.(int64)
-	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id"})
 		return
 	}
 
 	banks, err := h.store.SearchByCategory(context.Background(), userID, month, query)
 	if err != nil {
 		slog.Error("SearchByCategory failed", "error", err, "user_id", userID, "month", month, "category", query)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
 		return
 	}
 	c.JSON(http.StatusOK, banks)
@@ -172,35 +198,30 @@ func (h *CashbackHandler) SearchByCategory(c *gin.Context) {
 // @Param month query string true "Month in YYYY-MM format"
 // @Param q query string true "Bank name"
 // @Success 200 {array} domain.Category
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} problem.Problem
 // @Router /api/v1/search/bank [get]
 func (h *CashbackHandler) SearchByBank(c *gin.Context) {
+	lang := middleware.Lang(c)
 	month := c.Query("month")
 	query := c.Query("q")
 	if month == "" || query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month and q query params required"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_q_required")
 		return
 	}
 	if len(month) != 7 || month[4] != '-' {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month must be in YYYY-MM format"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_format")
 		return
 	}
 
-	userIDVal, ok := c.Get("user_id")
+	userID, ok := userIDFrom(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "user_id missing"})
-		return
-	}
-	userID, ok := userIDVal.(int64)
-	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id"})
 		return
 	}
 
 	categories, err := h.store.SearchByBank(context.Background(), userID, month, query)
 	if err != nil {
 		slog.Error("SearchByBank failed", "error", err, "user_id", userID, "month", month, "bank", query)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
 		return
 	}
 	c.JSON(http.StatusOK, categories)
@@ -211,37 +232,34 @@ func (h *CashbackHandler) SearchByBank(c *gin.Context) {
 // @Param month query string true "Month in YYYY-MM format"
 // @Param bank query string true "Bank name"
 // @Param request body UpdateCategoriesRequest true "New categories"
+// @Param dry_run query bool false "Only return the rows that would be inserted, updated and deleted"
 // @Success 200 {object} map[string]string{"status":"ok"}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
 // @Router /api/v1/month/bank [patch]
 func (h *CashbackHandler) UpdateBankCategories(c *gin.Context) {
+	lang := middleware.Lang(c)
 	month := c.Query("month")
 	bankName := c.Query("bank")
 	if month == "" || bankName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month and bank query params required"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_bank_required")
 		return
 	}
 
 	var req UpdateCategoriesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
 		return
 	}
 
-	if err := validateStruct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validRequest(c, lang, req) {
 		return
 	}
 
-	userIDVal, ok := c.Get("user_id")
-	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "user_id missing"})
-		return
-	}
-	userID, ok := userIDVal.(int64)
+	userID, ok := userIDFrom(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id"})
 		return
 	}
 
@@ -254,9 +272,36 @@ func (h *CashbackHandler) UpdateBankCategories(c *gin.Context) {
 		}
 	}
 
-	if err := h.store.UpdateBankCategories(context.Background(), userID, month, bankName, categories); err != nil {
+	// dry_run: категории банка заменяются целиком, остальные банки месяца не затрагиваются
+	if isDryRun(c) {
+		current, err := h.currentBanks(context.Background(), userID, month)
+		if err != nil {
+			slog.Error("UpdateBankCategories dry run failed", "error", err, "user_id", userID, "month", month, "bank", bankName)
+			problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+			return
+		}
+		var bankBefore []domain.BankWithCategories
+		for _, bwc := range current {
+			if bwc.Bank.Name == bankName {
+				bankBefore = append(bankBefore, bwc)
+			}
+		}
+		if len(bankBefore) == 0 {
+			problem.Abort(c, lang, http.StatusNotFound, "api.bank_not_in_month", bankName, month)
+			return
+		}
+		bankAfter := []domain.BankWithCategories{{Bank: domain.Bank{Name: bankName}, Categories: categories}}
+		writePlan(c, month, diff.Changes(bankBefore, bankAfter, diff.Replace))
+		return
+	}
+
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+	if _, err := h.store.UpdateBankCategories(ctx, userID, month, bankName, categories); err != nil {
 		slog.Error("UpdateBankCategories failed", "error", err, "user_id", userID, "month", month, "bank", bankName)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update"})
+		storageFailed(c, lang, err, "api.update_failed")
 		return
 	}
 
@@ -270,31 +315,28 @@ func (h *CashbackHandler) UpdateBankCategories(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body SaveMonthRequest true "Month data"
+// @Param dry_run query bool false "Only return the rows that would be inserted, updated and deleted"
 // @Success 200 {object} map[string]string{"status":"ok"}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
 // @Router /api/v1/month [patch]
 func (h *CashbackHandler) PatchMonth(c *gin.Context) {
+	lang := middleware.Lang(c)
 	slog.Info("PatchMonth request received")
 	var req SaveMonthRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
 		return
 	}
 
-	if err := validateStruct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !validRequest(c, lang, req) {
 		return
 	}
 
-	userIDVal, ok := c.Get("user_id")
-	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "user_id missing"})
-		return
-	}
-	userID, ok := userIDVal.(int64)
+	userID, ok := userIDFrom(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id"})
 		return
 	}
 
@@ -313,9 +355,24 @@ func (h *CashbackHandler) PatchMonth(c *gin.Context) {
 		}
 	}
 
-	if err := h.store.PatchMonth(context.Background(), userID, req.Month, bankCategories); err != nil {
+	if isDryRun(c) {
+		current, err := h.currentBanks(context.Background(), userID, req.Month)
+		if err != nil {
+			slog.Error("PatchMonth dry run failed", "error", err, "user_id", userID, "month", req.Month)
+			problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+			return
+		}
+		writePlan(c, req.Month, diff.Changes(current, bankCategories, diff.Merge))
+		return
+	}
+
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+	if _, err := h.store.PatchMonth(ctx, userID, req.Month, bankCategories); err != nil {
 		slog.Error("Failed to patch month", "error", err, "user_id", userID, "month", req.Month)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update month"})
+		storageFailed(c, lang, err, "api.update_month_failed")
 		return
 	}
 
@@ -328,31 +385,32 @@ func (h *CashbackHandler) PatchMonth(c *gin.Context) {
 // @Param month query string true "Month in YYYY-MM format"
 // @Param bank query string true "Bank name"
 // @Success 200 {object} map[string]string{"status":"ok"}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
 // @Router /api/v1/month/bank [delete]
 func (h *CashbackHandler) DeleteBankFromMonth(c *gin.Context) {
+	lang := middleware.Lang(c)
 	month := c.Query("month")
 	bankName := c.Query("bank")
 	if month == "" || bankName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month and bank query params required"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_bank_required")
 		return
 	}
 
-	userIDVal, ok := c.Get("user_id")
+	userID, ok := userIDFrom(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "user_id missing"})
 		return
 	}
-	userID, ok := userIDVal.(int64)
+
+	ctx, ok := ifMatch(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id"})
 		return
 	}
-
-	if err := h.store.DeleteBankFromMonth(context.Background(), userID, month, bankName); err != nil {
+	if err := h.store.DeleteBankFromMonth(ctx, userID, month, bankName); err != nil {
 		slog.Error("DeleteBankFromMonth failed", "error", err, "user_id", userID, "month", month, "bank", bankName)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		storageFailed(c, lang, err, "api.internal")
 		return
 	}
 
@@ -365,38 +423,437 @@ func (h *CashbackHandler) DeleteBankFromMonth(c *gin.Context) {
 // @Param bank query string true "Bank name"
 // @Param category query string true "Category name"
 // @Success 200 {object} map[string]string{"status":"ok"}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
 // @Router /api/v1/month/bank/category [delete]
 func (h *CashbackHandler) DeleteCategoryFromBank(c *gin.Context) {
+	lang := middleware.Lang(c)
 	month := c.Query("month")
 	bankName := c.Query("bank")
 	categoryName := c.Query("category")
 	if month == "" || bankName == "" || categoryName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "month, bank, and category query params required"})
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_bank_cat_required")
 		return
 	}
 
-	userIDVal, ok := c.Get("user_id")
+	userID, ok := userIDFrom(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "user_id missing"})
 		return
 	}
-	userID, ok := userIDVal.(int64)
+
+	ctx, ok := ifMatch(c, lang)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id"})
 		return
 	}
-
-	if err := h.store.DeleteCategoryFromBank(context.Background(), userID, month, bankName, categoryName); err != nil {
+	if err := h.store.DeleteCategoryFromBank(ctx, userID, month, bankName, categoryName); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			problem.Abort(c, lang, http.StatusNotFound, "api.category_not_found")
+			return
+		}
 		slog.Error("DeleteCategoryFromBank failed", "error", err, "user_id", userID, "month", month, "bank", bankName, "category", categoryName)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Нет подходящей категории"})
+		storageFailed(c, lang, err, "api.internal")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// SearchHistory godoc
+// @Summary Search cashback across months
+// @Description Filters are optional; the period defaults to the last 12 months. Newest months first.
+// @Param category query string false "Category name (case-insensitive)"
+// @Param bank query string false "Bank name (case-insensitive)"
+// @Param from query string false "From month, YYYY-MM"
+// @Param to query string false "To month, YYYY-MM"
+// @Param min_percent query number false "Minimal percent"
+// @Success 200 {array} domain.CashbackRow
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/search/history [get]
+func (h *CashbackHandler) SearchHistory(c *gin.Context) {
+	lang := middleware.Lang(c)
+	filter, err := historyFilter(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Category = c.Query("category")
+	filter.Bank = c.Query("bank")
+	if v := c.Query("min_percent"); v != "" {
+		p, err := strconv.ParseFloat(v, 32)
+		if err != nil || p < 0 || p > 100 {
+			problem.Abort(c, lang, http.StatusBadRequest, "api.min_percent_invalid")
+			return
+		}
+		filter.MinPercent = float32(p)
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	rows, err := h.store.SearchHistory(context.Background(), userID, filter)
+	if err != nil {
+		slog.Error("SearchHistory failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	if rows == nil {
+		rows = []domain.CashbackRow{}
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// historyFilter заполняет период поиска: по умолчанию — 12 месяцев до текущего включительно.
+// Ошибка содержит ключ каталога сообщений.
+func historyFilter(from, to string, now time.Time) (domain.HistoryFilter, error) {
+	if to == "" {
+		to = now.Format("2006-01")
+	}
+	toTime, err := time.Parse("2006-01", to)
+	if err != nil {
+		return domain.HistoryFilter{}, errors.New("api.from_to_format")
+	}
+	if from == "" {
+		from = toTime.AddDate(0, -11, 0).Format("2006-01")
+	}
+	fromTime, err := time.Parse("2006-01", from)
+	if err != nil {
+		return domain.HistoryFilter{}, errors.New("api.from_to_format")
+	}
+	if toTime.Before(fromTime) {
+		return domain.HistoryFilter{}, errors.New("api.from_after_to")
+	}
+	return domain.HistoryFilter{From: from, To: to}, nil
+}
+
+// Autocomplete godoc
+// @Summary Suggest bank or category names
+// @Description Partial and fuzzy (typo-tolerant) match among the user's banks or categories, best first
+// @Param type query string true "bank or category"
+// @Param q query string true "Typed text"
+// @Param limit query int false "Max suggestions (default 10, max 50)"
+// @Success 200 {array} string
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/autocomplete [get]
+func (h *CashbackHandler) Autocomplete(c *gin.Context) {
+	lang := middleware.Lang(c)
+	kind := domain.NameKind(c.Query("type"))
+	query := strings.TrimSpace(c.Query("q"))
+	if (kind != domain.NameBank && kind != domain.NameCategory) || query == "" {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.autocomplete_invalid")
+		return
+	}
+	limit := 10
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 {
+		limit = min(n, 50)
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	names, err := h.store.SuggestNames(context.Background(), userID, kind, query, limit)
+	if err != nil {
+		slog.Error("Autocomplete failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	if names == nil {
+		names = []string{}
+	}
+	c.JSON(http.StatusOK, names)
+}
+
+// Overview godoc
+// @Summary Year overview
+// @Description Month × bank × category matrix with percents for a year
+// @Param year query int true "Year, e.g. 2025"
+// @Success 200 {object} summary.YearOverview
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/overview [get]
+func (h *CashbackHandler) Overview(c *gin.Context) {
+	lang := middleware.Lang(c)
+	year, err := strconv.Atoi(c.Query("year"))
+	if err != nil || year < 2000 || year > 2100 {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.year_required")
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	from, to := fmt.Sprintf("%04d-01", year), fmt.Sprintf("%04d-12", year)
+	rows, err := h.store.GetRange(context.Background(), userID, from, to)
+	if err != nil {
+		slog.Error("Overview failed", "error", err, "user_id", userID, "year", year)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	c.JSON(http.StatusOK, summary.Overview(year, rows))
+}
+
+// MonthByCategory godoc
+// @Summary Month pivot by category
+// @Description Category → banks sorted by percent, highest first
+// @Param month query string true "Month in YYYY-MM format"
+// @Param category query string false "Only this category (case-insensitive)"
+// @Success 200 {array} domain.CategoryBanks
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/month/by-category [get]
+func (h *CashbackHandler) MonthByCategory(c *gin.Context) {
+	lang := middleware.Lang(c)
+	month := c.Query("month")
+	if _, err := time.Parse("2006-01", month); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_required")
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	result, err := h.store.GetMonthByCategory(context.Background(), userID, month, c.Query("category"))
+	if err != nil {
+		slog.Error("MonthByCategory failed", "error", err, "user_id", userID, "month", month)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	if result == nil {
+		result = []domain.CategoryBanks{}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// isDryRun — запрошен предпросмотр изменений без записи (?dry_run=true)
+func isDryRun(c *gin.Context) bool {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	return dryRun
+}
+
+// currentBanks — банки месяца в том виде, в каком они сейчас лежат в базе
+func (h *CashbackHandler) currentBanks(ctx context.Context, userID int64, month string) ([]domain.BankWithCategories, error) {
+	current, err := h.store.GetMonth(ctx, userID, month)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	return current.Banks, nil
+}
+
+// writePlan отвечает на dry_run: какие строки будут вставлены, обновлены и удалены
+func writePlan(c *gin.Context, month string, changes []diff.Change) {
+	c.JSON(http.StatusOK, PlanResponse{DryRun: true, Month: month, Plan: diff.NewPlan(changes)})
+}
+
+// PlanResponse — ответ на запись с dry_run
+type PlanResponse struct {
+	DryRun bool      `json:"dry_run"`
+	Month  string    `json:"month"`
+	Plan   diff.Plan `json:"plan"`
+}
+
+// MonthDiff godoc
+// @Summary Difference between two months
+// @Description Banks added/removed, categories gained/lost and percent changes per bank
+// @Param from query string true "Base month in YYYY-MM format"
+// @Param to query string true "Compared month in YYYY-MM format"
+// @Success 200 {object} diff.MonthDiff
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/month/diff [get]
+func (h *CashbackHandler) MonthDiff(c *gin.Context) {
+	lang := middleware.Lang(c)
+	from := c.Query("from")
+	to := c.Query("to")
+	if from == "" || to == "" {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.from_to_required")
+		return
+	}
+	_, errFrom := time.Parse("2006-01", from)
+	_, errTo := time.Parse("2006-01", to)
+	if errFrom != nil || errTo != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.from_to_format")
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	months, err := h.store.GetMonths(context.Background(), userID, []string{from, to})
+	if err != nil {
+		slog.Error("MonthDiff failed", "error", err, "user_id", userID, "from", from, "to", to)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	var oldBanks, newBanks []domain.BankWithCategories
+	for _, m := range months {
+		if m.Month == from {
+			oldBanks = m.Banks
+		}
+		if m.Month == to {
+			newBanks = m.Banks
+		}
+	}
+	c.JSON(http.StatusOK, diff.Months(from, oldBanks, to, newBanks))
+}
+
+// Export godoc
+// @Summary Export cashback data for a range of months
+// @Description One row per (month, bank, category, percent)
+// @Tags cashback
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce json
+// @Param from query string true "First month in YYYY-MM format"
+// @Param to query string true "Last month in YYYY-MM format"
+// @Param format query string false "csv (default), xlsx or json"
+// @Success 200 {file} file
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/export [get]
+func (h *CashbackHandler) Export(c *gin.Context) {
+	lang := middleware.Lang(c)
+	from := c.Query("from")
+	to := c.Query("to")
+	if from == "" || to == "" {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.from_to_required")
+		return
+	}
+	fromTime, errFrom := time.Parse("2006-01", from)
+	toTime, errTo := time.Parse("2006-01", to)
+	if errFrom != nil || errTo != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.from_to_format")
+		return
+	}
+	if toTime.Before(fromTime) {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.from_after_to")
+		return
+	}
+
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.format_invalid", c.Query("format"))
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	rows, err := h.store.GetRange(context.Background(), userID, from, to)
+	if err != nil {
+		slog.Error("Export failed", "error", err, "user_id", userID, "from", from, "to", to)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, rows); err != nil {
+		slog.Error("Export encoding failed", "error", err, "user_id", userID, "format", format)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, format.FileName(from, to)))
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
+// MaxImportSize — ограничение на размер загружаемого файла, самое большое тело
+// запроса API; его же использует middleware.Idempotency
+const MaxImportSize = 5 << 20
+
+// Import godoc
+// @Summary Import cashback data in the export format
+// @Description Validates every row, returns a per-month diff and applies it unless dry_run is set.
+// @Description Each month is applied atomically via SaveMonth (mode=replace) or PatchMonth (mode=merge).
+// @Tags cashback
+// @Accept text/csv
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param format query string false "csv (default) or json; detected from the file name if omitted"
+// @Param mode query string false "merge (default) or replace"
+// @Param dry_run query bool false "Only validate and return the diff"
+// @Param file formData file false "File to import (or send it as the raw body)"
+// @Success 200 {object} export.ImportResult
+// @Failure 400 {object} export.ImportResult
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/import [post]
+func (h *CashbackHandler) Import(c *gin.Context) {
+	lang := middleware.Lang(c)
+	mode, err := export.ParseMode(c.Query("mode"))
+	if err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.mode_invalid", c.Query("mode"))
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportSize)
+	var body io.Reader = c.Request.Body
+	formatHint := c.Query("format")
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			problem.Abort(c, lang, http.StatusBadRequest, "api.file_required")
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			problem.Abort(c, lang, http.StatusBadRequest, "api.file_unreadable")
+			return
+		}
+		defer f.Close()
+		body = f
+		if formatHint == "" {
+			formatHint = strings.TrimPrefix(filepath.Ext(fh.Filename), ".")
+		}
+	} else if formatHint == "" && strings.Contains(c.ContentType(), "json") {
+		formatHint = string(export.FormatJSON)
+	}
+
+	format, err := export.ParseFormat(formatHint)
+	if err != nil || format == export.FormatXLSX {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.import_format_invalid")
+		return
+	}
+
+	rows, rowErrs, err := export.Read(body, format)
+	if err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.import_unreadable", err)
+		return
+	}
+
+	result, err := export.Import(context.Background(), h.store, userID, rows, rowErrs, mode, dryRun)
+	if result != nil {
+		result.Localize(lang)
+	}
+	if err != nil {
+		if errors.Is(err, export.ErrInvalidRows) {
+			c.JSON(http.StatusBadRequest, result)
+			return
+		}
+		slog.Error("Import failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+
+	slog.Info("Import processed", "user_id", userID, "rows", result.Rows, "months", len(result.Months), "dry_run", dryRun)
+	c.JSON(http.StatusOK, result)
+}
+
 // === DTO ===
 
 type SaveMonthRequest struct {
@@ -407,7 +864,7 @@ type SaveMonthRequest struct {
 			Name    string  `json:"name" validate:"required,notblank"`
 			Percent float32 `json:"percent" validate:"required,gte=0,lte=100"`
 		} `json:"categories" validate:"required,min=1,dive"`
-	} `json:"banks" validate:"required,min=1"`
+	} `json:"banks" validate:"required,min=1,dive"`
 }
 
 type UpdateCategoriesRequest struct {
@@ -417,33 +874,49 @@ type UpdateCategoriesRequest struct {
 	} `json:"categories" validate:"required,min=1,dive"`
 }
 
-func validateStruct(v any) error {
-	if err := val.Validate.Struct(v); err != nil {
-		var errs []string
-		for _, e := range err.(validator.ValidationErrors) {
-			errs = append(errs, fieldErrorToString(e))
-		}
-		return fmt.Errorf("invalid input: %s", strings.Join(errs, "; "))
+// validRequest проверяет тело запроса; при ошибках сам отвечает 400 со списком полей
+func validRequest(c *gin.Context, lang i18n.Lang, v any) bool {
+	err := val.Validate.Struct(v)
+	if err == nil {
+		return true
+	}
+	var fields []problem.FieldError
+	for _, e := range err.(validator.ValidationErrors) {
+		fields = append(fields, problem.FieldError{
+			Field:   fieldPath(e),
+			Code:    e.Tag(),
+			Message: fieldErrorToString(lang, e),
+		})
+	}
+	problem.Invalid(c, lang, fields)
+	return false
+}
+
+// fieldPath — путь к полю в JSON без имени корневой структуры: banks[0].categories[1].percent
+func fieldPath(e validator.FieldError) string {
+	ns := e.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
 	}
-	return nil
+	return ns
 }
 
-func fieldErrorToString(e validator.FieldError) string {
+func fieldErrorToString(lang i18n.Lang, e validator.FieldError) string {
 	switch e.Tag() {
 	case "required":
-		return fmt.Sprintf("%s is required", e.Field())
+		return i18n.T(lang, "validation.required", e.Field())
 	case "yearmonth":
-		return fmt.Sprintf("%s must be in YYYY-MM format", e.Field())
+		return i18n.T(lang, "validation.yearmonth", e.Field())
 	case "notblank":
-		return fmt.Sprintf("%s must not be blank", e.Field())
+		return i18n.T(lang, "validation.notblank", e.Field())
 	case "min":
 		if e.Param() == "1" {
-			return fmt.Sprintf("%s must not be empty", e.Field())
+			return i18n.T(lang, "validation.min_empty", e.Field())
 		}
-		return fmt.Sprintf("%s is too short", e.Field())
+		return i18n.T(lang, "validation.min", e.Field())
 	case "gte", "lte":
-		return fmt.Sprintf("%s must be between 0 and 100", e.Field())
+		return i18n.T(lang, "validation.range", e.Field())
 	default:
-		return fmt.Sprintf("%s is invalid", e.Field())
+		return i18n.T(lang, "validation.invalid", e.Field())
 	}
 }
\ No newline at end of file
@@ -0,0 +1,77 @@
+// internal/handler/common.go
+package handler
+
+import (
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/problem"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userIDFrom достаёт user_id, положенный AuthMiddleware; при ошибке сам отвечает 500
+func userIDFrom(c *gin.Context, lang i18n.Lang) (int64, bool) {
+	userIDVal, ok := c.Get("user_id")
+	if !ok {
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.user_id_missing")
+		return 0, false
+	}
+	userID, ok := userIDVal.(int64)
+	if !ok {
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.invalid_user_id")
+		return 0, false
+	}
+	return userID, true
+}
+
+// storageFailed отвечает на ошибку хранилища по её типу (см. storageError)
+func storageFailed(c *gin.Context, lang i18n.Lang, err error, failKey string) {
+	status, key, args := storageError(lang, err, failKey)
+	problem.Abort(c, lang, status, key, args...)
+}
+
+// storageError выбирает статус и текст по типу ошибки хранилища: ErrNotFound — 404,
+// ErrConflict — 409, ErrStaleVersion — 412, ErrValidation — 400, остальное — 500 с failKey
+func storageError(lang i18n.Lang, err error, failKey string) (status int, key string, args []any) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound, "api.not_found", nil
+	case errors.Is(err, storage.ErrConflict):
+		return http.StatusConflict, "api.conflict", nil
+	case errors.Is(err, storage.ErrStaleVersion):
+		return http.StatusPreconditionFailed, "api.version_mismatch", nil
+	case errors.Is(err, storage.ErrValidation):
+		// подробности — на языке запроса; ErrValidation без них переводится общим текстом
+		invalid := &storage.ValidationError{Key: "storage.invalid"}
+		errors.As(err, &invalid)
+		return http.StatusBadRequest, "api.invalid_data", []any{invalid.Text(lang)}
+	default:
+		return http.StatusInternalServerError, failKey, nil
+	}
+}
+
+// etag — ETag месяца: его версия в кавычках, "0" — месяц ещё не сохранялся
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatch переносит If-Match в контекст записи (storage.WithVersion). Без заголовка
+// и с "*" версия не проверяется; нечисловой ETag ни с чем не совпадёт — сразу 412.
+func ifMatch(c *gin.Context, lang i18n.Lang) (context.Context, bool) {
+	ctx := context.Background()
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return ctx, true
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version < 0 {
+		problem.Abort(c, lang, http.StatusPreconditionFailed, "api.version_mismatch")
+		return nil, false
+	}
+	return storage.WithVersion(ctx, version), true
+}
@@ -0,0 +1,140 @@
+// internal/handler/deadline.go
+package handler
+
+import (
+	"cashback-tracker/internal/deadline"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeadlineHandler struct {
+	deadlines *deadline.Service
+}
+
+func NewDeadlineHandler(deadlines *deadline.Service) *DeadlineHandler {
+	return &DeadlineHandler{deadlines: deadlines}
+}
+
+type DeadlineRuleRequest struct {
+	Bank        string `json:"bank" validate:"required,notblank"`
+	Day         int    `json:"day" validate:"required"`
+	MonthOffset int    `json:"month_offset"`
+}
+
+// GetDeadlines godoc
+// @Summary Upcoming category selection deadlines for the user's banks
+// @Success 200 {array} domain.Deadline
+// @Router /api/v1/deadlines [get]
+func (h *DeadlineHandler) GetDeadlines(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	deadlines, err := h.deadlines.Upcoming(context.Background(), userID, time.Now())
+	if err != nil {
+		slog.Error("GetDeadlines failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	if deadlines == nil {
+		deadlines = []domain.Deadline{}
+	}
+	c.JSON(http.StatusOK, deadlines)
+}
+
+// GetRules godoc
+// @Summary List the user's bank deadline rules
+// @Success 200 {array} domain.DeadlineRule
+// @Router /api/v1/deadlines/rules [get]
+func (h *DeadlineHandler) GetRules(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	rules, err := h.deadlines.Rules(context.Background(), userID)
+	if err != nil {
+		slog.Error("GetRules failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	if rules == nil {
+		rules = []domain.DeadlineRule{}
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// SetRule godoc
+// @Summary Set a bank's category selection deadline for the user
+// @Description month_offset 0 — deadline in the same month, -1 — in the previous month
+// @Accept json
+// @Produce json
+// @Param request body DeadlineRuleRequest true "Rule"
+// @Success 200 {object} map[string]string{"status":"ok"}
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/deadlines/rules [put]
+func (h *DeadlineHandler) SetRule(c *gin.Context) {
+	lang := middleware.Lang(c)
+	var req DeadlineRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	rule := domain.DeadlineRule{Bank: req.Bank, Day: req.Day, MonthOffset: req.MonthOffset}
+	err := h.deadlines.SetRule(context.Background(), userID, rule)
+	if key := deadline.ErrorKey(err); key != "" {
+		problem.Abort(c, lang, http.StatusBadRequest, key)
+		return
+	}
+	if err != nil {
+		slog.Error("SetRule failed", "error", err, "user_id", userID, "bank", req.Bank)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.update_failed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// DeleteRule godoc
+// @Summary Delete the user's deadline rule for a bank
+// @Param bank query string true "Bank name"
+// @Success 200 {object} map[string]string{"status":"ok"}
+// @Router /api/v1/deadlines/rules [delete]
+func (h *DeadlineHandler) DeleteRule(c *gin.Context) {
+	lang := middleware.Lang(c)
+	bank := c.Query("bank")
+	if bank == "" {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.bank_required")
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	if err := h.deadlines.DeleteRule(context.Background(), userID, bank); err != nil {
+		slog.Error("DeleteRule failed", "error", err, "user_id", userID, "bank", bank)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.update_failed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,119 @@
+// internal/handler/events.go
+package handler
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/events"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval — как часто в тихий поток уходит комментарий, чтобы прокси не рвали соединение
+const heartbeatInterval = 25 * time.Second
+
+// resetEvent — событие потока, после которого клиенту нужно перечитать данные целиком:
+// часть изменений после его Last-Event-ID уже не восстановить
+const resetEvent = "reset"
+
+type EventsHandler struct {
+	bus *events.Bus
+}
+
+func NewEventsHandler(bus *events.Bus) *EventsHandler {
+	return &EventsHandler{bus: bus}
+}
+
+// Stream godoc
+// @Summary Stream changes as Server-Sent Events
+// @Description Streams month, bank and category changes of the current user (from the API and the bot).
+// @Description Each event carries an id; reconnect with Last-Event-ID (header or last_event_id query) to receive missed events.
+// @Description A "reset" event means missed events are no longer available and data should be fetched again.
+// @Tags events
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "Id of the last received event"
+// @Param last_event_id query int false "Same as Last-Event-ID, for clients that cannot set headers"
+// @Success 200 {object} domain.Event
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/events [get]
+func (h *EventsHandler) Stream(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	lastID := int64(0)
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id < 0 {
+			problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_last_event_id")
+			return
+		}
+		lastID = id
+	}
+
+	sub, missed, complete := h.bus.Subscribe(userID, lastID)
+	defer h.bus.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// nginx иначе копит поток в буфере
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprintf(c.Writer, "event: %s\ndata: {}\n\n", resetEvent)
+	}
+	for _, e := range missed {
+		if err := writeEvent(c, e); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+	slog.Info("Events stream opened", "user_id", userID, "last_event_id", lastID, "missed", len(missed))
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				// отстали или сервер останавливается — клиент переподключится с Last-Event-ID
+				return
+			}
+			if err := writeEvent(c, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(c *gin.Context, e domain.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
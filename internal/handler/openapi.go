@@ -0,0 +1,484 @@
+// internal/handler/openapi.go
+package handler
+
+import (
+	"cashback-tracker/internal/batch"
+	"cashback-tracker/internal/diff"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/export"
+	"cashback-tracker/internal/openapi"
+	"cashback-tracker/internal/problem"
+	"cashback-tracker/internal/summary"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusResponse — тело ответа {"status":"ok"} у записей API v1
+type StatusResponse struct {
+	Status string `json:"status" validate:"oneof=ok"`
+}
+
+// LoginRequest — тело POST /api/v1/login
+type LoginRequest struct {
+	UserID int64 `json:"user_id" validate:"required"`
+}
+
+// LoginResponse — JWT для заголовка Authorization: Bearer
+type LoginResponse struct {
+	Token string `json:"token"`
+}
+
+// OpenAPI описывает все маршруты API. Схемы тел выводятся из DTO этого пакета.
+// TestOpenAPIMatchesRoutes сверяет документ с маршрутами Register (openapi.Document.Check):
+// новый маршрут без описания здесь или описание без маршрута роняют тест.
+func OpenAPI() *openapi.Document {
+	d := openapi.New(openapi.Info{
+		Title:       "Cashback Tracker API",
+		Version:     "1.0",
+		Description: "Cashback categories by month, bank and category. Errors are RFC 7807 problem+json with a stable `code`.",
+	})
+	d.UseBearerAuth()
+
+	var (
+		monthQuery = openapi.QueryParam("month", "Month in YYYY-MM format", true, openapi.Month())
+		monthPath  = openapi.PathParam("month", "Month in YYYY-MM format", openapi.Month())
+		bankPath   = openapi.PathParam("bank", "Bank name", openapi.String())
+		catPath    = openapi.PathParam("category", "Category name", openapi.String())
+		hookPath   = openapi.PathParam("id", "Webhook id", openapi.Integer())
+		ifMatch    = openapi.HeaderParam("If-Match", "ETag from GET; a stale version returns 412", openapi.String())
+		dryRun     = openapi.QueryParam("dry_run", "Only return the rows that would be inserted, updated and deleted", false, openapi.Boolean())
+
+		failed = func(description string) *openapi.Response {
+			return d.Content(description, problem.ContentType, problem.Problem{})
+		}
+		invalid  = failed("Invalid request")
+		notFound = failed("Not found")
+		conflict = failed("Conflict")
+		stale    = failed("The month has changed since the ETag was issued")
+		internal = failed("Internal error")
+
+		ok      = d.JSON("OK", StatusResponse{})
+		deleted = d.JSON("Deleted", nil)
+	)
+	month := func(description string) *openapi.Response {
+		return d.JSON(description, domain.CashbackMonth{}).WithHeader("ETag", "Month version")
+	}
+	bank := func(description string) *openapi.Response {
+		return d.JSON(description, domain.BankWithCategories{}).WithHeader("ETag", "Month version")
+	}
+	category := func(description string) *openapi.Response {
+		return d.JSON(description, domain.CashbackCategory{}).WithHeader("ETag", "Month version")
+	}
+	// запись v1 с dry_run отвечает планом изменений вместо {"status":"ok"}
+	okOrPlan := &openapi.Response{Description: "OK, or the plan of changes with dry_run", Content: map[string]*openapi.MediaType{
+		"application/json": {Schema: &openapi.Schema{OneOf: []*openapi.Schema{d.Schema(StatusResponse{}), d.Schema(PlanResponse{})}}},
+	}}
+
+	// --- служебные ---
+	d.Add(http.MethodGet, "/health", openapi.Operation{
+		Summary:   "Health check",
+		Tags:      []string{"service"},
+		Responses: map[string]*openapi.Response{"200": ok},
+		Security:  openapi.Public(),
+	})
+	d.Add(http.MethodPost, "/api/v1/login", openapi.Operation{
+		Summary:     "Get a token for a user",
+		Tags:        []string{"auth"},
+		RequestBody: d.Body(LoginRequest{}),
+		Responses:   map[string]*openapi.Response{"200": d.JSON("Token", LoginResponse{}), "400": invalid, "500": internal},
+		Security:    openapi.Public(),
+	})
+
+	// --- v1: месяц ---
+	saveMonth := openapi.Operation{
+		Summary:     "Save cashback categories for a month",
+		Description: "Replaces the month: banks and categories not in the body are removed",
+		Tags:        []string{"cashback"},
+		Parameters:  []openapi.Parameter{dryRun, ifMatch},
+		RequestBody: d.Body(SaveMonthRequest{}),
+		Responses:   map[string]*openapi.Response{"200": okOrPlan, "400": invalid, "412": stale, "500": internal},
+	}
+	d.Add(http.MethodPost, "/api/v1/month", saveMonth)
+	d.Add(http.MethodPut, "/api/v1/month", saveMonth)
+	d.Add(http.MethodGet, "/api/v1/month", openapi.Operation{
+		Summary:    "Get cashback for a month",
+		Tags:       []string{"cashback"},
+		Parameters: []openapi.Parameter{monthQuery, openapi.HeaderParam("If-None-Match", "ETag from a previous GET; an unchanged month returns 304", openapi.String())},
+		Responses:  map[string]*openapi.Response{"200": month("Month"), "304": d.JSON("Not modified", nil), "400": invalid},
+	})
+	d.Add(http.MethodPatch, "/api/v1/month", openapi.Operation{
+		Summary:     "Partially update cashback for a month (add/update banks/categories)",
+		Description: "Add or update banks and categories without removing others",
+		Tags:        []string{"cashback"},
+		Parameters:  []openapi.Parameter{dryRun, ifMatch},
+		RequestBody: d.Body(SaveMonthRequest{}),
+		Responses:   map[string]*openapi.Response{"200": okOrPlan, "400": invalid, "412": stale, "500": internal},
+	})
+	d.Add(http.MethodPatch, "/api/v1/month/bank", openapi.Operation{
+		Summary:     "Update categories for a bank in a month",
+		Tags:        []string{"cashback"},
+		Parameters:  []openapi.Parameter{monthQuery, openapi.QueryParam("bank", "Bank name", true, openapi.String()), dryRun, ifMatch},
+		RequestBody: d.Body(UpdateCategoriesRequest{}),
+		Responses:   map[string]*openapi.Response{"200": okOrPlan, "400": invalid, "404": notFound, "412": stale, "500": internal},
+	})
+	d.Add(http.MethodDelete, "/api/v1/month/bank", openapi.Operation{
+		Summary:    "Delete a bank from a month",
+		Tags:       []string{"cashback"},
+		Parameters: []openapi.Parameter{monthQuery, openapi.QueryParam("bank", "Bank name", true, openapi.String()), ifMatch},
+		Responses:  map[string]*openapi.Response{"200": ok, "400": invalid, "404": notFound, "412": stale, "500": internal},
+	})
+	d.Add(http.MethodDelete, "/api/v1/month/bank/category", openapi.Operation{
+		Summary: "Delete a category from a bank in a month",
+		Tags:    []string{"cashback"},
+		Parameters: []openapi.Parameter{
+			monthQuery,
+			openapi.QueryParam("bank", "Bank name", true, openapi.String()),
+			openapi.QueryParam("category", "Category name", true, openapi.String()),
+			ifMatch,
+		},
+		Responses: map[string]*openapi.Response{"200": ok, "400": invalid, "404": notFound, "412": stale, "500": internal},
+	})
+	d.Add(http.MethodGet, "/api/v1/month/by-category", openapi.Operation{
+		Summary:    "Month pivot by category",
+		Tags:       []string{"cashback"},
+		Parameters: []openapi.Parameter{monthQuery, openapi.QueryParam("category", "Only this category (case-insensitive)", false, openapi.String())},
+		Responses:  map[string]*openapi.Response{"200": d.JSON("Categories with their banks", []domain.CategoryBanks{}), "400": invalid},
+	})
+	d.Add(http.MethodGet, "/api/v1/month/diff", openapi.Operation{
+		Summary: "Difference between two months",
+		Tags:    []string{"cashback"},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("from", "Base month in YYYY-MM format", true, openapi.Month()),
+			openapi.QueryParam("to", "Compared month in YYYY-MM format", true, openapi.Month()),
+		},
+		Responses: map[string]*openapi.Response{"200": d.JSON("Difference", diff.MonthDiff{}), "400": invalid},
+	})
+	d.Add(http.MethodGet, "/api/v1/overview", openapi.Operation{
+		Summary:    "Year overview",
+		Tags:       []string{"cashback"},
+		Parameters: []openapi.Parameter{openapi.QueryParam("year", "Year, e.g. 2025", true, openapi.Integer())},
+		Responses:  map[string]*openapi.Response{"200": d.JSON("Overview", summary.YearOverview{}), "400": invalid},
+	})
+	// лимит операций не в теге validate (см. BatchRequest), поэтому дописываем его в схему
+	batchBody := d.Body(BatchRequest{})
+	maxOps := batch.MaxOperations
+	d.Components.Schemas["handler.BatchRequest"].Properties["operations"].MaxItems = &maxOps
+	d.Add(http.MethodPost, "/api/v1/batch", openapi.Operation{
+		Summary:     "Run several writes atomically",
+		Description: "Runs an ordered list of operations in one transaction: either all are applied or none",
+		Tags:        []string{"cashback"},
+		Parameters:  []openapi.Parameter{openapi.QueryParam("dry_run", "Run the batch and roll it back", false, openapi.Boolean())},
+		RequestBody: batchBody,
+		Responses: map[string]*openapi.Response{
+			"200": d.JSON("Per-operation results", BatchResponse{}),
+			"400": invalid, "404": notFound, "409": conflict, "412": stale, "500": internal,
+		},
+	})
+
+	// --- v1: поиск ---
+	d.Add(http.MethodGet, "/api/v1/search/category", openapi.Operation{
+		Summary:    "Search banks by category name",
+		Tags:       []string{"search"},
+		Parameters: []openapi.Parameter{monthQuery, openapi.QueryParam("q", "Category name", true, openapi.String())},
+		Responses:  map[string]*openapi.Response{"200": d.JSON("Banks", []domain.Bank{}), "400": invalid},
+	})
+	d.Add(http.MethodGet, "/api/v1/search/bank", openapi.Operation{
+		Summary:    "Search categories by bank name",
+		Tags:       []string{"search"},
+		Parameters: []openapi.Parameter{monthQuery, openapi.QueryParam("q", "Bank name", true, openapi.String())},
+		Responses:  map[string]*openapi.Response{"200": d.JSON("Categories", []domain.Category{}), "400": invalid},
+	})
+	d.Add(http.MethodGet, "/api/v1/search/history", openapi.Operation{
+		Summary: "Search cashback across months",
+		Tags:    []string{"search"},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("category", "Category name (case-insensitive)", false, openapi.String()),
+			openapi.QueryParam("bank", "Bank name (case-insensitive)", false, openapi.String()),
+			openapi.QueryParam("from", "From month, YYYY-MM", false, openapi.Month()),
+			openapi.QueryParam("to", "To month, YYYY-MM", false, openapi.Month()),
+			openapi.QueryParam("min_percent", "Minimal percent", false, openapi.Number()),
+		},
+		Responses: map[string]*openapi.Response{"200": d.JSON("Rows, newest months first", []domain.CashbackRow{}), "400": invalid},
+	})
+	d.Add(http.MethodGet, "/api/v1/autocomplete", openapi.Operation{
+		Summary: "Suggest bank or category names",
+		Tags:    []string{"search"},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("type", "What to suggest", true, openapi.Enum("bank", "category")),
+			openapi.QueryParam("q", "Typed text", true, openapi.String()),
+			openapi.QueryParam("limit", "Max suggestions (default 10, max 50)", false, openapi.Integer()),
+		},
+		Responses: map[string]*openapi.Response{"200": d.JSON("Names", []string{}), "400": invalid},
+	})
+
+	// --- v1: выгрузка и загрузка ---
+	d.Add(http.MethodGet, "/api/v1/export", openapi.Operation{
+		Summary: "Export cashback data for a range of months",
+		Tags:    []string{"cashback"},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("from", "First month in YYYY-MM format", true, openapi.Month()),
+			openapi.QueryParam("to", "Last month in YYYY-MM format", true, openapi.Month()),
+			openapi.QueryParam("format", "File format (default csv)", false, openapi.Enum("csv", "xlsx", "json")),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "File", Content: map[string]*openapi.MediaType{
+				"text/csv": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+				"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+				"application/json": {Schema: d.Schema([]domain.CashbackRow{})},
+			}},
+			"400": invalid, "500": internal,
+		},
+	})
+	d.Add(http.MethodPost, "/api/v1/import", openapi.Operation{
+		Summary:     "Import cashback data in the export format",
+		Description: "Validates every row, returns a per-month diff and applies it unless dry_run is set. The file goes in the multipart field `file` or as the raw body.",
+		Tags:        []string{"cashback"},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("format", "File format; detected from the file name if omitted", false, openapi.Enum("csv", "json")),
+			openapi.QueryParam("mode", "How to apply the file (default merge)", false, openapi.Enum("merge", "replace")),
+			openapi.QueryParam("dry_run", "Only validate and return the diff", false, openapi.Boolean()),
+		},
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+			"multipart/form-data": {Schema: &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+				"file": {Type: "string", Format: "binary"},
+			}}},
+			"text/csv":         {Schema: openapi.String()},
+			"application/json": {Schema: d.Schema([]domain.CashbackRow{})},
+		}},
+		Responses: map[string]*openapi.Response{
+			"200": d.JSON("Import result", export.ImportResult{}),
+			"400": d.JSON("Invalid rows; nothing was applied", export.ImportResult{}),
+			"500": internal,
+		},
+	})
+
+	// --- v1: события и webhooks ---
+	d.Add(http.MethodGet, "/api/v1/events", openapi.Operation{
+		Summary:     "Stream changes as Server-Sent Events",
+		Description: "Each event has an id; reconnect with Last-Event-ID to receive missed events. A `reset` event means they are no longer available and data should be fetched again.",
+		Tags:        []string{"events"},
+		Parameters: []openapi.Parameter{
+			openapi.HeaderParam("Last-Event-ID", "Id of the last received event", openapi.String()),
+			openapi.QueryParam("last_event_id", "Same as Last-Event-ID, for clients that cannot set headers", false, openapi.Integer()),
+		},
+		Responses: map[string]*openapi.Response{"200": d.Content("Event stream; data of each event", "text/event-stream", domain.Event{}), "400": invalid},
+	})
+	d.Add(http.MethodPost, "/api/v1/webhooks", openapi.Operation{
+		Summary:     "Register a webhook",
+		Description: "Events are POSTed as JSON signed with X-Cashback-Signature: t=<unix>,v1=<hex HMAC-SHA256 of \"<t>.<body>\">. The secret is returned only here.",
+		Tags:        []string{"webhooks"},
+		RequestBody: d.Body(CreateWebhookRequest{}),
+		Responses:   map[string]*openapi.Response{"201": d.JSON("Created", domain.Webhook{}).WithHeader("Location", "URL of the webhook"), "400": invalid, "409": conflict},
+	})
+	d.Add(http.MethodGet, "/api/v1/webhooks", openapi.Operation{
+		Summary:   "List webhooks",
+		Tags:      []string{"webhooks"},
+		Responses: map[string]*openapi.Response{"200": d.JSON("Webhooks", []domain.Webhook{})},
+	})
+	d.Add(http.MethodGet, "/api/v1/webhooks/{id}", openapi.Operation{
+		Summary:    "Get a webhook",
+		Tags:       []string{"webhooks"},
+		Parameters: []openapi.Parameter{hookPath},
+		Responses:  map[string]*openapi.Response{"200": d.JSON("Webhook", domain.Webhook{}), "404": notFound},
+	})
+	d.Add(http.MethodPatch, "/api/v1/webhooks/{id}", openapi.Operation{
+		Summary:     "Change a webhook",
+		Tags:        []string{"webhooks"},
+		Parameters:  []openapi.Parameter{hookPath},
+		RequestBody: d.Body(UpdateWebhookRequest{}),
+		Responses:   map[string]*openapi.Response{"200": d.JSON("Webhook", domain.Webhook{}), "400": invalid, "404": notFound},
+	})
+	d.Add(http.MethodDelete, "/api/v1/webhooks/{id}", openapi.Operation{
+		Summary:    "Delete a webhook",
+		Tags:       []string{"webhooks"},
+		Parameters: []openapi.Parameter{hookPath},
+		Responses:  map[string]*openapi.Response{"204": deleted, "404": notFound},
+	})
+	d.Add(http.MethodGet, "/api/v1/webhooks/{id}/deliveries", openapi.Operation{
+		Summary:    "Webhook delivery log",
+		Tags:       []string{"webhooks"},
+		Parameters: []openapi.Parameter{hookPath, openapi.QueryParam("limit", "How many deliveries to return (default 50, max 200)", false, openapi.Integer())},
+		Responses:  map[string]*openapi.Response{"200": d.JSON("Deliveries, newest first", []domain.WebhookDelivery{}), "400": invalid, "404": notFound},
+	})
+
+	// --- v1: напоминания, сроки, итоги ---
+	d.Add(http.MethodGet, "/api/v1/reminder", openapi.Operation{
+		Summary:   "Get reminder settings",
+		Tags:      []string{"reminders"},
+		Responses: map[string]*openapi.Response{"200": d.JSON("Settings", domain.ReminderSettings{}), "404": notFound},
+	})
+	d.Add(http.MethodPut, "/api/v1/reminder", openapi.Operation{
+		Summary:     "Set reminder settings",
+		Tags:        []string{"reminders"},
+		RequestBody: d.Body(ReminderRequest{}),
+		Responses:   map[string]*openapi.Response{"200": d.JSON("Settings with the next run", ReminderResponse{}), "400": invalid},
+	})
+	d.Add(http.MethodDelete, "/api/v1/reminder", openapi.Operation{
+		Summary:   "Delete reminder settings",
+		Tags:      []string{"reminders"},
+		Responses: map[string]*openapi.Response{"200": ok},
+	})
+	d.Add(http.MethodGet, "/api/v1/deadlines", openapi.Operation{
+		Summary:   "Upcoming category selection deadlines for the user's banks",
+		Tags:      []string{"deadlines"},
+		Responses: map[string]*openapi.Response{"200": d.JSON("Deadlines", []domain.Deadline{})},
+	})
+	d.Add(http.MethodGet, "/api/v1/deadlines/rules", openapi.Operation{
+		Summary:   "List the user's bank deadline rules",
+		Tags:      []string{"deadlines"},
+		Responses: map[string]*openapi.Response{"200": d.JSON("Rules", []domain.DeadlineRule{})},
+	})
+	d.Add(http.MethodPut, "/api/v1/deadlines/rules", openapi.Operation{
+		Summary:     "Set a bank's category selection deadline for the user",
+		Tags:        []string{"deadlines"},
+		RequestBody: d.Body(DeadlineRuleRequest{}),
+		Responses:   map[string]*openapi.Response{"200": ok, "400": invalid},
+	})
+	d.Add(http.MethodDelete, "/api/v1/deadlines/rules", openapi.Operation{
+		Summary:    "Delete the user's deadline rule for a bank",
+		Tags:       []string{"deadlines"},
+		Parameters: []openapi.Parameter{openapi.QueryParam("bank", "Bank name", true, openapi.String())},
+		Responses:  map[string]*openapi.Response{"200": ok},
+	})
+	d.Add(http.MethodGet, "/api/v1/summary", openapi.Operation{
+		Summary:    "Month summary",
+		Tags:       []string{"summary"},
+		Parameters: []openapi.Parameter{monthQuery},
+		Responses:  map[string]*openapi.Response{"200": d.JSON("Summary", summary.MonthSummary{}), "400": invalid},
+	})
+	d.Add(http.MethodPost, "/api/v1/purchases", openapi.Operation{
+		Summary:     "Log a purchase",
+		Tags:        []string{"summary"},
+		RequestBody: d.Body(PurchaseRequest{}),
+		Responses:   map[string]*openapi.Response{"200": ok, "400": invalid},
+	})
+
+	// --- v2: вложенные ресурсы ---
+	d.Add(http.MethodGet, "/api/v2/months/{month}", openapi.Operation{
+		Summary:    "Get a month",
+		Tags:       []string{"v2"},
+		Parameters: []openapi.Parameter{monthPath},
+		Responses:  map[string]*openapi.Response{"200": month("Month"), "404": notFound},
+	})
+	d.Add(http.MethodPut, "/api/v2/months/{month}", openapi.Operation{
+		Summary:     "Create or replace a month",
+		Description: "Everything not in the body is removed from the month",
+		Tags:        []string{"v2"},
+		Parameters:  []openapi.Parameter{monthPath, ifMatch},
+		RequestBody: d.Body(PutMonthRequest{}),
+		Responses:   map[string]*openapi.Response{"200": month("Replaced"), "201": month("Created"), "400": invalid, "412": stale},
+	})
+	d.Add(http.MethodPatch, "/api/v2/months/{month}", openapi.Operation{
+		Summary:     "Add or update banks and categories of an existing month",
+		Tags:        []string{"v2"},
+		Parameters:  []openapi.Parameter{monthPath, ifMatch},
+		RequestBody: d.Body(PutMonthRequest{}),
+		Responses:   map[string]*openapi.Response{"200": month("Updated"), "400": invalid, "404": notFound, "412": stale},
+	})
+	d.Add(http.MethodDelete, "/api/v2/months/{month}", openapi.Operation{
+		Summary:    "Delete a month with all its banks",
+		Tags:       []string{"v2"},
+		Parameters: []openapi.Parameter{monthPath, ifMatch},
+		Responses:  map[string]*openapi.Response{"204": deleted, "404": notFound, "412": stale},
+	})
+	d.Add(http.MethodGet, "/api/v2/months/{month}/banks", openapi.Operation{
+		Summary:    "Banks of a month",
+		Tags:       []string{"v2"},
+		Parameters: []openapi.Parameter{monthPath},
+		Responses: map[string]*openapi.Response{
+			"200": d.JSON("Banks", []domain.BankWithCategories{}).WithHeader("ETag", "Month version"),
+			"404": notFound,
+		},
+	})
+	d.Add(http.MethodGet, "/api/v2/months/{month}/banks/{bank}", openapi.Operation{
+		Summary:    "A bank of a month with its categories",
+		Tags:       []string{"v2"},
+		Parameters: []openapi.Parameter{monthPath, bankPath},
+		Responses:  map[string]*openapi.Response{"200": bank("Bank"), "404": notFound},
+	})
+	d.Add(http.MethodPut, "/api/v2/months/{month}/banks/{bank}", openapi.Operation{
+		Summary:     "Create a bank in a month or replace its categories",
+		Tags:        []string{"v2"},
+		Parameters:  []openapi.Parameter{monthPath, bankPath, ifMatch},
+		RequestBody: d.Body(UpdateCategoriesRequest{}),
+		Responses:   map[string]*openapi.Response{"200": bank("Replaced"), "201": bank("Created"), "400": invalid, "412": stale},
+	})
+	d.Add(http.MethodDelete, "/api/v2/months/{month}/banks/{bank}", openapi.Operation{
+		Summary:    "Remove a bank from a month",
+		Tags:       []string{"v2"},
+		Parameters: []openapi.Parameter{monthPath, bankPath, ifMatch},
+		Responses:  map[string]*openapi.Response{"204": deleted, "404": notFound, "412": stale},
+	})
+	d.Add(http.MethodGet, "/api/v2/months/{month}/banks/{bank}/categories/{category}", openapi.Operation{
+		Summary:    "A category of a bank in a month",
+		Tags:       []string{"v2"},
+		Parameters: []openapi.Parameter{monthPath, bankPath, catPath},
+		Responses:  map[string]*openapi.Response{"200": category("Category"), "404": notFound},
+	})
+	d.Add(http.MethodPut, "/api/v2/months/{month}/banks/{bank}/categories/{category}", openapi.Operation{
+		Summary:     "Create a category in a bank or change its percent",
+		Tags:        []string{"v2"},
+		Parameters:  []openapi.Parameter{monthPath, bankPath, catPath, ifMatch},
+		RequestBody: d.Body(PutCategoryRequest{}),
+		Responses:   map[string]*openapi.Response{"200": category("Changed"), "201": category("Created"), "400": invalid, "412": stale},
+	})
+	d.Add(http.MethodDelete, "/api/v2/months/{month}/banks/{bank}/categories/{category}", openapi.Operation{
+		Summary:    "Remove a category from a bank",
+		Tags:       []string{"v2"},
+		Parameters: []openapi.Parameter{monthPath, bankPath, catPath, ifMatch},
+		Responses:  map[string]*openapi.Response{"204": deleted, "404": notFound, "412": stale},
+	})
+
+	// все операции под авторизацией могут ответить 401
+	unauthorized := failed("Missing or invalid token")
+	for _, ops := range d.Paths {
+		for _, op := range ops {
+			if op.Security == nil {
+				op.Responses["401"] = unauthorized
+			}
+		}
+	}
+	return d
+}
+
+// DocsHandler отдаёт OpenAPI-документ и страницу с интерактивной документацией
+type DocsHandler struct {
+	spec []byte
+}
+
+func NewDocsHandler(doc *openapi.Document) (*DocsHandler, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &DocsHandler{spec: spec}, nil
+}
+
+// Spec — GET /openapi.json
+func (h *DocsHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", h.spec)
+}
+
+// UI — GET /docs: Swagger UI, который читает /openapi.json
+func (h *DocsHandler) UI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Cashback Tracker API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui", persistAuthorization: true });
+  </script>
+</body>
+</html>
+`
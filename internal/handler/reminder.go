@@ -0,0 +1,132 @@
+// internal/handler/reminder.go
+package handler
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
+	"cashback-tracker/internal/reminder"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReminderHandler struct {
+	reminders *reminder.Service
+}
+
+func NewReminderHandler(reminders *reminder.Service) *ReminderHandler {
+	return &ReminderHandler{reminders: reminders}
+}
+
+type ReminderRequest struct {
+	Day      int    `json:"day" validate:"required"`
+	Time     string `json:"time" validate:"required"`
+	Timezone string `json:"timezone"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+type ReminderResponse struct {
+	domain.ReminderSettings
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+// GetReminder godoc
+// @Summary Get reminder settings
+// @Success 200 {object} domain.ReminderSettings
+// @Failure 404 {object} problem.Problem
+// @Router /api/v1/reminder [get]
+func (h *ReminderHandler) GetReminder(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	settings, err := h.reminders.Get(context.Background(), userID)
+	if err != nil {
+		slog.Error("GetReminder failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	if settings == nil {
+		problem.Abort(c, lang, http.StatusNotFound, "api.reminder_not_found")
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// SaveReminder godoc
+// @Summary Set reminder settings
+// @Description Day of month, HH:MM time and IANA timezone; enabled defaults to true
+// @Accept json
+// @Produce json
+// @Param request body ReminderRequest true "Reminder settings"
+// @Success 200 {object} ReminderResponse
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/reminder [put]
+func (h *ReminderHandler) SaveReminder(c *gin.Context) {
+	lang := middleware.Lang(c)
+	var req ReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	settings := domain.ReminderSettings{
+		UserID:   userID,
+		Day:      req.Day,
+		Time:     req.Time,
+		Timezone: req.Timezone,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	next, err := h.reminders.Save(context.Background(), settings)
+	if key := reminder.ErrorKey(err); key != "" {
+		problem.Abort(c, lang, http.StatusBadRequest, key)
+		return
+	}
+	if err != nil {
+		slog.Error("SaveReminder failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+
+	if settings.Timezone == "" {
+		settings.Timezone = reminder.DefaultTimezone
+	}
+	resp := ReminderResponse{ReminderSettings: settings}
+	if !next.IsZero() {
+		resp.NextRun = &next
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteReminder godoc
+// @Summary Delete reminder settings
+// @Success 200 {object} map[string]string{"status":"ok"}
+// @Router /api/v1/reminder [delete]
+func (h *ReminderHandler) DeleteReminder(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	if err := h.reminders.Delete(context.Background(), userID); err != nil {
+		slog.Error("DeleteReminder failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,584 @@
+// internal/handler/resources.go
+package handler
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResourceHandler — API v2: месяцы, банки месяца и категории банка как вложенные
+// ресурсы с идентификаторами в пути (/months/{month}/banks/{bank}/categories/{category})
+type ResourceHandler struct {
+	store CombinedStorage
+}
+
+func NewResourceHandler(store CombinedStorage) *ResourceHandler {
+	return &ResourceHandler{store: store}
+}
+
+type PutMonthRequest struct {
+	Banks []struct {
+		Name       string `json:"name" validate:"required,notblank"`
+		Categories []struct {
+			Name    string  `json:"name" validate:"required,notblank"`
+			Percent float32 `json:"percent" validate:"required,gte=0,lte=100"`
+		} `json:"categories" validate:"required,min=1,dive"`
+	} `json:"banks" validate:"required,min=1,dive"`
+}
+
+type PutCategoryRequest struct {
+	Percent float32 `json:"percent" validate:"required,gte=0,lte=100"`
+}
+
+// monthFromPath проверяет {month} и достаёт user_id; при ошибке сам отвечает
+func monthFromPath(c *gin.Context, lang i18n.Lang) (int64, string, bool) {
+	month := c.Param("month")
+	if _, err := time.Parse("2006-01", month); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_format")
+		return 0, "", false
+	}
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return 0, "", false
+	}
+	return userID, month, true
+}
+
+// load читает месяц; nil — месяца нет. При ошибке базы сам отвечает 500.
+func (h *ResourceHandler) load(c *gin.Context, lang i18n.Lang, userID int64, month string) (*domain.CashbackMonth, bool) {
+	current, err := h.store.GetMonth(context.Background(), userID, month)
+	if err != nil {
+		slog.Error("v2: GetMonth failed", "error", err, "user_id", userID, "month", month)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return nil, false
+	}
+	if current != nil && len(current.Banks) == 0 {
+		current = nil
+	}
+	return current, true
+}
+
+// exists — месяц есть и в нём есть банки; пустой месяц API считает отсутствующим, как load
+func exists(month *domain.CashbackMonth) bool {
+	return month != nil && len(month.Banks) > 0
+}
+
+func findBank(month *domain.CashbackMonth, name string) *domain.BankWithCategories {
+	if month == nil {
+		return nil
+	}
+	for i := range month.Banks {
+		if month.Banks[i].Bank.Name == name {
+			return &month.Banks[i]
+		}
+	}
+	return nil
+}
+
+func findCategory(bank *domain.BankWithCategories, name string) *domain.CashbackCategory {
+	if bank == nil {
+		return nil
+	}
+	for i := range bank.Categories {
+		if bank.Categories[i].Category.Name == name {
+			return &bank.Categories[i]
+		}
+	}
+	return nil
+}
+
+func monthLocation(month string) string {
+	return "/api/v2/months/" + month
+}
+
+func bankLocation(month, bank string) string {
+	return monthLocation(month) + "/banks/" + url.PathEscape(bank)
+}
+
+func categoryLocation(month, bank, category string) string {
+	return bankLocation(month, bank) + "/categories/" + url.PathEscape(category)
+}
+
+// GetMonth godoc
+// @Summary Get a month
+// @Tags v2
+// @Param month path string true "Month in YYYY-MM format"
+// @Success 200 {object} domain.CashbackMonth
+// @Failure 404 {object} problem.Problem
+// @Header 200 {string} ETag "Month version"
+// @Router /api/v2/months/{month} [get]
+func (h *ResourceHandler) GetMonth(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	current, ok := h.load(c, lang, userID, month)
+	if !ok {
+		return
+	}
+	if current == nil {
+		problem.Abort(c, lang, http.StatusNotFound, "api.month_not_found", month)
+		return
+	}
+	c.Header("ETag", etag(current.Version))
+	c.JSON(http.StatusOK, current)
+}
+
+// PutMonth godoc
+// @Summary Create or replace a month
+// @Description Everything not in the body is removed from the month
+// @Tags v2
+// @Accept json
+// @Produce json
+// @Param month path string true "Month in YYYY-MM format"
+// @Param request body PutMonthRequest true "Banks and categories"
+// @Success 200 {object} domain.CashbackMonth
+// @Success 201 {object} domain.CashbackMonth
+// @Failure 400 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
+// @Router /api/v2/months/{month} [put]
+func (h *ResourceHandler) PutMonth(c *gin.Context) {
+	h.writeMonth(c, false)
+}
+
+// PatchMonth godoc
+// @Summary Add or update banks and categories of an existing month
+// @Tags v2
+// @Accept json
+// @Produce json
+// @Param month path string true "Month in YYYY-MM format"
+// @Param request body PutMonthRequest true "Banks and categories"
+// @Success 200 {object} domain.CashbackMonth
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
+// @Router /api/v2/months/{month} [patch]
+func (h *ResourceHandler) PatchMonth(c *gin.Context) {
+	h.writeMonth(c, true)
+}
+
+// writeMonth — общая часть PUT и PATCH месяца: PUT заменяет месяц и создаёт его
+// при отсутствии (201), PATCH дописывает только в существующий месяц
+func (h *ResourceHandler) writeMonth(c *gin.Context, patch bool) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+
+	var req PutMonthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+
+	banks := make([]domain.BankWithCategories, len(req.Banks))
+	for i, bankReq := range req.Banks {
+		categories := make([]domain.CashbackCategory, len(bankReq.Categories))
+		for j, catReq := range bankReq.Categories {
+			categories[j] = domain.CashbackCategory{Category: domain.Category{Name: catReq.Name}, Percent: catReq.Percent}
+		}
+		banks[i] = domain.BankWithCategories{Bank: domain.Bank{Name: bankReq.Name}, Categories: categories}
+	}
+
+	if patch {
+		current, ok := h.load(c, lang, userID, month)
+		if !ok {
+			return
+		}
+		if current == nil {
+			problem.Abort(c, lang, http.StatusNotFound, "api.month_not_found", month)
+			return
+		}
+	}
+
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+
+	var write *domain.MonthWrite
+	var err error
+	if patch {
+		write, err = h.store.PatchMonth(ctx, userID, month, banks)
+	} else {
+		write, err = h.store.SaveMonth(ctx, userID, month, banks)
+	}
+	if err != nil {
+		slog.Error("v2: write month failed", "error", err, "user_id", userID, "month", month, "patch", patch)
+		storageFailed(c, lang, err, "api.save_failed")
+		return
+	}
+
+	status := http.StatusOK
+	if !exists(write.Before) {
+		status = http.StatusCreated
+		c.Header("Location", monthLocation(month))
+	}
+	respondMonth(c, status, month, write)
+}
+
+// DeleteMonth godoc
+// @Summary Delete a month with all its banks
+// @Tags v2
+// @Param month path string true "Month in YYYY-MM format"
+// @Success 204
+// @Failure 404 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
+// @Router /api/v2/months/{month} [delete]
+func (h *ResourceHandler) DeleteMonth(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	current, ok := h.load(c, lang, userID, month)
+	if !ok {
+		return
+	}
+	if current == nil {
+		problem.Abort(c, lang, http.StatusNotFound, "api.month_not_found", month)
+		return
+	}
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteMonth(ctx, userID, month); err != nil {
+		slog.Error("v2: DeleteMonth failed", "error", err, "user_id", userID, "month", month)
+		storageFailed(c, lang, err, "api.internal")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListBanks godoc
+// @Summary Banks of a month
+// @Tags v2
+// @Param month path string true "Month in YYYY-MM format"
+// @Success 200 {array} domain.BankWithCategories
+// @Failure 404 {object} problem.Problem
+// @Header 200 {string} ETag "Month version"
+// @Router /api/v2/months/{month}/banks [get]
+func (h *ResourceHandler) ListBanks(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	current, ok := h.load(c, lang, userID, month)
+	if !ok {
+		return
+	}
+	if current == nil {
+		problem.Abort(c, lang, http.StatusNotFound, "api.month_not_found", month)
+		return
+	}
+	c.Header("ETag", etag(current.Version))
+	c.JSON(http.StatusOK, current.Banks)
+}
+
+// GetBank godoc
+// @Summary A bank of a month with its categories
+// @Tags v2
+// @Param month path string true "Month in YYYY-MM format"
+// @Param bank path string true "Bank name"
+// @Success 200 {object} domain.BankWithCategories
+// @Failure 404 {object} problem.Problem
+// @Header 200 {string} ETag "Month version"
+// @Router /api/v2/months/{month}/banks/{bank} [get]
+func (h *ResourceHandler) GetBank(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	current, ok := h.load(c, lang, userID, month)
+	if !ok {
+		return
+	}
+	bankName := c.Param("bank")
+	bank := findBank(current, bankName)
+	if bank == nil {
+		problem.Abort(c, lang, http.StatusNotFound, "api.bank_not_in_month", bankName, month)
+		return
+	}
+	c.Header("ETag", etag(current.Version))
+	c.JSON(http.StatusOK, bank)
+}
+
+// PutBank godoc
+// @Summary Create a bank in a month or replace its categories
+// @Tags v2
+// @Accept json
+// @Produce json
+// @Param month path string true "Month in YYYY-MM format"
+// @Param bank path string true "Bank name"
+// @Param request body UpdateCategoriesRequest true "Categories of the bank"
+// @Success 200 {object} domain.BankWithCategories
+// @Success 201 {object} domain.BankWithCategories
+// @Failure 400 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
+// @Router /api/v2/months/{month}/banks/{bank} [put]
+func (h *ResourceHandler) PutBank(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	bankName := c.Param("bank")
+
+	var req UpdateCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+	categories := make([]domain.CashbackCategory, len(req.Categories))
+	for i, catReq := range req.Categories {
+		categories[i] = domain.CashbackCategory{Category: domain.Category{Name: catReq.Name}, Percent: catReq.Percent}
+	}
+
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+	// существующему банку заменяем категории, нового дописываем в месяц
+	write, err := h.store.UpdateBankCategories(ctx, userID, month, bankName, categories)
+	if errors.Is(err, storage.ErrNotFound) {
+		write, err = h.store.PatchMonth(ctx, userID, month, []domain.BankWithCategories{{
+			Bank:       domain.Bank{Name: bankName},
+			Categories: categories,
+		}})
+	}
+	if err != nil {
+		slog.Error("v2: PutBank failed", "error", err, "user_id", userID, "month", month, "bank", bankName)
+		storageFailed(c, lang, err, "api.update_failed")
+		return
+	}
+
+	status := http.StatusOK
+	if findBank(write.Before, bankName) == nil {
+		status = http.StatusCreated
+		c.Header("Location", bankLocation(month, bankName))
+	}
+	respondBank(c, status, bankName, write)
+}
+
+// DeleteBank godoc
+// @Summary Remove a bank from a month
+// @Tags v2
+// @Param month path string true "Month in YYYY-MM format"
+// @Param bank path string true "Bank name"
+// @Success 204
+// @Failure 404 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
+// @Router /api/v2/months/{month}/banks/{bank} [delete]
+func (h *ResourceHandler) DeleteBank(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	current, ok := h.load(c, lang, userID, month)
+	if !ok {
+		return
+	}
+	bankName := c.Param("bank")
+	if findBank(current, bankName) == nil {
+		problem.Abort(c, lang, http.StatusNotFound, "api.bank_not_in_month", bankName, month)
+		return
+	}
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteBankFromMonth(ctx, userID, month, bankName); err != nil {
+		slog.Error("v2: DeleteBank failed", "error", err, "user_id", userID, "month", month, "bank", bankName)
+		storageFailed(c, lang, err, "api.internal")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetCategory godoc
+// @Summary A category of a bank in a month
+// @Tags v2
+// @Param month path string true "Month in YYYY-MM format"
+// @Param bank path string true "Bank name"
+// @Param category path string true "Category name"
+// @Success 200 {object} domain.CashbackCategory
+// @Failure 404 {object} problem.Problem
+// @Header 200 {string} ETag "Month version"
+// @Router /api/v2/months/{month}/banks/{bank}/categories/{category} [get]
+func (h *ResourceHandler) GetCategory(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	current, ok := h.load(c, lang, userID, month)
+	if !ok {
+		return
+	}
+	bankName, categoryName := c.Param("bank"), c.Param("category")
+	cc := findCategory(findBank(current, bankName), categoryName)
+	if cc == nil {
+		problem.Abort(c, lang, http.StatusNotFound, "api.category_not_in_bank", categoryName, bankName, month)
+		return
+	}
+	c.Header("ETag", etag(current.Version))
+	c.JSON(http.StatusOK, cc)
+}
+
+// PutCategory godoc
+// @Summary Create a category in a bank or change its percent
+// @Description Creates the bank in the month if needed
+// @Tags v2
+// @Accept json
+// @Produce json
+// @Param month path string true "Month in YYYY-MM format"
+// @Param bank path string true "Bank name"
+// @Param category path string true "Category name"
+// @Param request body PutCategoryRequest true "Percent"
+// @Success 200 {object} domain.CashbackCategory
+// @Success 201 {object} domain.CashbackCategory
+// @Failure 400 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
+// @Header 200 {string} ETag "Month version after the write"
+// @Router /api/v2/months/{month}/banks/{bank}/categories/{category} [put]
+func (h *ResourceHandler) PutCategory(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	bankName, categoryName := c.Param("bank"), c.Param("category")
+
+	var req PutCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+	write, err := h.store.PatchMonth(ctx, userID, month, []domain.BankWithCategories{{
+		Bank:       domain.Bank{Name: bankName},
+		Categories: []domain.CashbackCategory{{Category: domain.Category{Name: categoryName}, Percent: req.Percent}},
+	}})
+	if err != nil {
+		slog.Error("v2: PutCategory failed", "error", err, "user_id", userID, "month", month, "bank", bankName, "category", categoryName)
+		storageFailed(c, lang, err, "api.update_failed")
+		return
+	}
+
+	status := http.StatusOK
+	if findCategory(findBank(write.Before, bankName), categoryName) == nil {
+		status = http.StatusCreated
+		c.Header("Location", categoryLocation(month, bankName, categoryName))
+	}
+	respondCategory(c, status, bankName, categoryName, write)
+}
+
+// DeleteCategory godoc
+// @Summary Remove a category from a bank
+// @Tags v2
+// @Param month path string true "Month in YYYY-MM format"
+// @Param bank path string true "Bank name"
+// @Param category path string true "Category name"
+// @Success 204
+// @Failure 404 {object} problem.Problem
+// @Param If-Match header string false "ETag from GET; a stale version returns 412"
+// @Failure 412 {object} problem.Problem
+// @Router /api/v2/months/{month}/banks/{bank}/categories/{category} [delete]
+func (h *ResourceHandler) DeleteCategory(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, month, ok := monthFromPath(c, lang)
+	if !ok {
+		return
+	}
+	current, ok := h.load(c, lang, userID, month)
+	if !ok {
+		return
+	}
+	bankName, categoryName := c.Param("bank"), c.Param("category")
+	if findCategory(findBank(current, bankName), categoryName) == nil {
+		problem.Abort(c, lang, http.StatusNotFound, "api.category_not_in_bank", categoryName, bankName, month)
+		return
+	}
+	ctx, ok := ifMatch(c, lang)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteCategoryFromBank(ctx, userID, month, bankName, categoryName); err != nil {
+		slog.Error("v2: DeleteCategory failed", "error", err, "user_id", userID, "month", month, "bank", bankName, "category", categoryName)
+		storageFailed(c, lang, err, "api.internal")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondMonth отвечает месяцем в том виде, в каком его оставила запись, и ETag её
+// версии: ответ не перечитывает месяц и не покажет чужую запись, сделанную следом
+func respondMonth(c *gin.Context, status int, month string, write *domain.MonthWrite) {
+	after := write.After
+	if after == nil {
+		after = &domain.CashbackMonth{Month: month, Banks: []domain.BankWithCategories{}}
+	}
+	c.Header("ETag", etag(after.Version))
+	c.JSON(status, after)
+}
+
+// respondBank отвечает банком месяца в том виде, в каком его оставила запись
+func respondBank(c *gin.Context, status int, bankName string, write *domain.MonthWrite) {
+	bank := findBank(write.After, bankName)
+	if bank == nil {
+		bank = &domain.BankWithCategories{Bank: domain.Bank{Name: bankName}, Categories: []domain.CashbackCategory{}}
+	}
+	if write.After != nil {
+		c.Header("ETag", etag(write.After.Version))
+	}
+	c.JSON(status, bank)
+}
+
+// respondCategory отвечает категорией банка в том виде, в каком её оставила запись,
+// с ETag новой версии месяца — следующую условную запись можно делать без GET
+func respondCategory(c *gin.Context, status int, bankName, categoryName string, write *domain.MonthWrite) {
+	category := findCategory(findBank(write.After, bankName), categoryName)
+	if category == nil {
+		category = &domain.CashbackCategory{Category: domain.Category{Name: categoryName}}
+	}
+	if write.After != nil {
+		c.Header("ETag", etag(write.After.Version))
+	}
+	c.JSON(status, category)
+}
@@ -0,0 +1,123 @@
+// internal/handler/router.go
+package handler
+
+import (
+	"cashback-tracker/internal/auth"
+	"cashback-tracker/internal/deadline"
+	"cashback-tracker/internal/events"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
+	"cashback-tracker/internal/reminder"
+	"cashback-tracker/internal/storage"
+	"cashback-tracker/internal/summary"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Store — всё, что нужно маршрутам API от хранилища
+type Store interface {
+	CombinedStorage
+	storage.IdempotencyStorage
+	storage.WebhookStorage
+	reminder.Store
+	deadline.Store
+	summary.Store
+}
+
+// Register добавляет маршруты API: /health, вход, v1 и v2. OpenAPI() описывает
+// ровно их — это проверяет router_test.go. Telegram webhook и сама документация
+// в документ не входят и добавляются отдельно.
+func Register(router gin.IRouter, store Store, tokens *auth.TokenService, bus *events.Bus, idempotencyTTL time.Duration) {
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.POST("/api/v1/login", login(tokens))
+
+	authMiddleware := middleware.NewAuthMiddleware(tokens)
+	idempotency := middleware.Idempotency(store, idempotencyTTL, MaxImportSize)
+	cashback := NewCashbackHandler(store)
+
+	v1 := router.Group("/api/v1")
+	v1.Use(authMiddleware.RequireAuth(), idempotency)
+	{
+		v1.POST("/month", cashback.SaveMonth)
+		v1.GET("/month", cashback.GetMonth)
+		v1.GET("/month/by-category", cashback.MonthByCategory)
+		v1.GET("/month/diff", cashback.MonthDiff)
+		v1.GET("/overview", cashback.Overview)
+		v1.GET("/search/category", cashback.SearchByCategory)
+		v1.GET("/search/bank", cashback.SearchByBank)
+		v1.GET("/search/history", cashback.SearchHistory)
+		v1.GET("/autocomplete", cashback.Autocomplete)
+		v1.PUT("/month", cashback.SaveMonth)
+		v1.PATCH("/month", cashback.PatchMonth)
+		v1.PATCH("/month/bank", cashback.UpdateBankCategories)
+		v1.DELETE("/month/bank", cashback.DeleteBankFromMonth)
+		v1.DELETE("/month/bank/category", cashback.DeleteCategoryFromBank)
+		v1.GET("/export", cashback.Export)
+		v1.POST("/import", cashback.Import)
+		v1.POST("/batch", cashback.Batch)
+		v1.GET("/events", NewEventsHandler(bus).Stream)
+
+		reminders := NewReminderHandler(reminder.NewService(store))
+		v1.GET("/reminder", reminders.GetReminder)
+		v1.PUT("/reminder", reminders.SaveReminder)
+		v1.DELETE("/reminder", reminders.DeleteReminder)
+
+		deadlines := NewDeadlineHandler(deadline.NewService(store))
+		v1.GET("/deadlines", deadlines.GetDeadlines)
+		v1.GET("/deadlines/rules", deadlines.GetRules)
+		v1.PUT("/deadlines/rules", deadlines.SetRule)
+		v1.DELETE("/deadlines/rules", deadlines.DeleteRule)
+
+		webhooks := NewWebhookHandler(store)
+		v1.POST("/webhooks", webhooks.CreateWebhook)
+		v1.GET("/webhooks", webhooks.ListWebhooks)
+		v1.GET("/webhooks/:id", webhooks.GetWebhook)
+		v1.PATCH("/webhooks/:id", webhooks.UpdateWebhook)
+		v1.DELETE("/webhooks/:id", webhooks.DeleteWebhook)
+		v1.GET("/webhooks/:id/deliveries", webhooks.ListDeliveries)
+
+		summaries := NewSummaryHandler(summary.NewService(store), store)
+		v1.GET("/summary", summaries.GetSummary)
+		v1.POST("/purchases", summaries.AddPurchase)
+	}
+
+	// v2: идентификаторы в пути, месяцы, банки и категории — вложенные ресурсы
+	v2 := router.Group("/api/v2")
+	v2.Use(authMiddleware.RequireAuth(), idempotency)
+	{
+		resources := NewResourceHandler(store)
+		v2.GET("/months/:month", resources.GetMonth)
+		v2.PUT("/months/:month", resources.PutMonth)
+		v2.PATCH("/months/:month", resources.PatchMonth)
+		v2.DELETE("/months/:month", resources.DeleteMonth)
+		v2.GET("/months/:month/banks", resources.ListBanks)
+		v2.GET("/months/:month/banks/:bank", resources.GetBank)
+		v2.PUT("/months/:month/banks/:bank", resources.PutBank)
+		v2.DELETE("/months/:month/banks/:bank", resources.DeleteBank)
+		v2.GET("/months/:month/banks/:bank/categories/:category", resources.GetCategory)
+		v2.PUT("/months/:month/banks/:bank/categories/:category", resources.PutCategory)
+		v2.DELETE("/months/:month/banks/:bank/categories/:category", resources.DeleteCategory)
+	}
+}
+
+// login — POST /api/v1/login: токен для пользователя
+func login(tokens *auth.TokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req LoginRequest
+		lang := middleware.Lang(c)
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Abort(c, lang, http.StatusBadRequest, "api.login.user_id_required")
+			return
+		}
+		token, err := tokens.GenerateToken(req.UserID)
+		if err != nil {
+			problem.Abort(c, lang, http.StatusInternalServerError, "api.login.token_failed")
+			return
+		}
+		c.JSON(http.StatusOK, LoginResponse{Token: token})
+	}
+}
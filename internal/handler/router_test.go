@@ -0,0 +1,36 @@
+// internal/handler/router_test.go
+package handler
+
+import (
+	"cashback-tracker/internal/auth"
+	"cashback-tracker/internal/config"
+	"cashback-tracker/internal/events"
+	"cashback-tracker/internal/openapi"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Документ API описывает ровно те маршруты, что добавляет Register: новый
+// маршрут без описания или описание удалённого маршрута ломают этот тест
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// обработчики не вызываются, хранилище не нужно
+	Register(router, nil, auth.NewTokenService(config.Config{JWTSecret: "test"}), events.NewBus(1), time.Hour)
+
+	routes := make([]openapi.Route, 0, len(router.Routes()))
+	for _, r := range router.Routes() {
+		routes = append(routes, openapi.Route{Method: r.Method, Path: r.Path})
+	}
+	if err := OpenAPI().Check(routes); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAPIValidJSON(t *testing.T) {
+	if _, err := NewDocsHandler(OpenAPI()); err != nil {
+		t.Fatal(err)
+	}
+}
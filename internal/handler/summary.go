@@ -0,0 +1,102 @@
+// internal/handler/summary.go
+package handler
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
+	"cashback-tracker/internal/storage"
+	"cashback-tracker/internal/summary"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SummaryHandler struct {
+	summaries *summary.Service
+	purchases storage.PurchaseStorage
+}
+
+func NewSummaryHandler(summaries *summary.Service, purchases storage.PurchaseStorage) *SummaryHandler {
+	return &SummaryHandler{summaries: summaries, purchases: purchases}
+}
+
+type PurchaseRequest struct {
+	Date     string  `json:"date"`
+	Bank     string  `json:"bank" validate:"required,notblank"`
+	Category string  `json:"category" validate:"required,notblank"`
+	Amount   float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// GetSummary godoc
+// @Summary Month summary
+// @Description Banks and categories, cashback earned from logged purchases, unused categories and comparison with the previous month
+// @Param month query string true "Month in YYYY-MM format"
+// @Success 200 {object} summary.MonthSummary
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/summary [get]
+func (h *SummaryHandler) GetSummary(c *gin.Context) {
+	lang := middleware.Lang(c)
+	month := c.Query("month")
+	if _, err := time.Parse("2006-01", month); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.month_required")
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	result, err := h.summaries.Build(context.Background(), userID, month)
+	if err != nil {
+		slog.Error("GetSummary failed", "error", err, "user_id", userID, "month", month)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// AddPurchase godoc
+// @Summary Log a purchase
+// @Description date defaults to today (YYYY-MM-DD)
+// @Accept json
+// @Produce json
+// @Param request body PurchaseRequest true "Purchase"
+// @Success 200 {object} map[string]string{"status":"ok"}
+// @Failure 400 {object} problem.Problem
+// @Router /api/v1/purchases [post]
+func (h *SummaryHandler) AddPurchase(c *gin.Context) {
+	lang := middleware.Lang(c)
+	var req PurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+	if req.Date == "" {
+		req.Date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.date_format")
+		return
+	}
+
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	purchase := domain.Purchase{Date: req.Date, Bank: req.Bank, Category: req.Category, Amount: req.Amount}
+	if err := h.purchases.AddPurchase(context.Background(), userID, purchase); err != nil {
+		slog.Error("AddPurchase failed", "error", err, "user_id", userID)
+		storageFailed(c, lang, err, "api.purchase_failed")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,54 @@
+// internal/handler/telegram.go
+package handler
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SecretTokenHeader — заголовок, в котором Telegram присылает secret_token из setWebhook
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// UpdateQueue принимает обновление в асинхронную обработку без ожидания
+type UpdateQueue interface {
+	Enqueue(update tgbotapi.Update) error
+}
+
+type TelegramHandler struct {
+	queue  UpdateQueue
+	secret string
+}
+
+func NewTelegramHandler(queue UpdateQueue, secret string) *TelegramHandler {
+	return &TelegramHandler{queue: queue, secret: secret}
+}
+
+// Webhook принимает обновления от Telegram. Запросы без верного секрета отклоняются.
+// Обработка идёт в фоне, поэтому Telegram сразу получает 200; если очередь
+// переполнена, отвечаем 503, и Telegram повторит доставку позже.
+func (h *TelegramHandler) Webhook(c *gin.Context) {
+	token := c.GetHeader(SecretTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) != 1 {
+		slog.Warn("Webhook: неверный secret token", "remote_addr", c.ClientIP())
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := c.ShouldBindJSON(&update); err != nil {
+		slog.Error("Ошибка парсинга обновления", "error", err)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.queue.Enqueue(update); err != nil {
+		slog.Warn("Обновление не принято в очередь", "error", err, "update_id", update.UpdateID)
+		c.Status(http.StatusServiceUnavailable)
+		return
+	}
+	c.Status(http.StatusOK)
+}
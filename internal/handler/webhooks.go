@@ -0,0 +1,319 @@
+// internal/handler/webhooks.go
+package handler
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/i18n"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/internal/problem"
+	"cashback-tracker/internal/storage"
+	"cashback-tracker/internal/webhook"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deliveriesLimit — сколько последних доставок показывает журнал по умолчанию и максимум
+const (
+	deliveriesLimit    = 50
+	maxDeliveriesLimit = 200
+)
+
+type WebhookHandler struct {
+	store storage.WebhookStorage
+}
+
+func NewWebhookHandler(store storage.WebhookStorage) *WebhookHandler {
+	return &WebhookHandler{store: store}
+}
+
+// CreateWebhookRequest — пустой events подписывает на все события
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events" validate:"omitempty,dive,oneof=month.saved month.patched month.deleted bank.updated bank.deleted category.deleted"`
+}
+
+// UpdateWebhookRequest — отсутствующие поля не меняются; "events": [] — все события
+type UpdateWebhookRequest struct {
+	URL     *string  `json:"url" validate:"omitempty,url"`
+	Events  []string `json:"events" validate:"omitempty,dive,oneof=month.saved month.patched month.deleted bank.updated bank.deleted category.deleted"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// webhookFromPath достаёт user_id и {id}; несуществующий id отвечает 404, как и чужой
+func webhookFromPath(c *gin.Context, lang i18n.Lang) (int64, int64, bool) {
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		problem.Abort(c, lang, http.StatusNotFound, "api.webhook_not_found")
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+func eventTypes(names []string) []domain.EventType {
+	types := make([]domain.EventType, len(names))
+	for i, name := range names {
+		types[i] = domain.EventType(name)
+	}
+	return types
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Registers an HTTPS URL that receives change events as signed JSON POST requests.
+// @Description The secret is returned only here; X-Cashback-Signature is "t=<unix>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body CreateWebhookRequest true "URL and event filter"
+// @Success 201 {object} domain.Webhook
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	lang := middleware.Lang(c)
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+	if err := webhook.ValidateURL(c.Request.Context(), req.URL); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.webhook_url_invalid")
+		return
+	}
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		slog.Error("Webhook secret failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	hook, err := h.store.CreateWebhook(ctx, domain.Webhook{
+		UserID:  userID,
+		URL:     req.URL,
+		Secret:  secret,
+		Events:  eventTypes(req.Events),
+		Enabled: true,
+	}, webhook.MaxPerUser)
+	if errors.Is(err, storage.ErrLimitExceeded) {
+		problem.Abort(c, lang, http.StatusConflict, "api.webhook_limit", webhook.MaxPerUser)
+		return
+	}
+	if err != nil {
+		slog.Error("CreateWebhook failed", "error", err, "user_id", userID)
+		storageFailed(c, lang, err, "api.internal")
+		return
+	}
+
+	// секрет показывается один раз: в сохранённом для Idempotency-Key ответе его нет
+	redacted := *hook
+	redacted.Secret = ""
+	if err := middleware.SetReplayBody(c, redacted); err != nil {
+		slog.Error("Webhook replay body failed", "error", err, "user_id", userID)
+	}
+
+	slog.Info("Webhook created", "user_id", userID, "webhook_id", hook.ID)
+	c.Header("Location", "/api/v1/webhooks/"+strconv.FormatInt(hook.ID, 10))
+	c.JSON(http.StatusCreated, hook)
+}
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} domain.Webhook
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, ok := userIDFrom(c, lang)
+	if !ok {
+		return
+	}
+	hooks, err := h.store.ListWebhooks(context.Background(), userID)
+	if err != nil {
+		slog.Error("ListWebhooks failed", "error", err, "user_id", userID)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	if hooks == nil {
+		hooks = []domain.Webhook{}
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Success 200 {object} domain.Webhook
+// @Failure 404 {object} problem.Problem
+// @Router /api/v1/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, id, ok := webhookFromPath(c, lang)
+	if !ok {
+		return
+	}
+	hook, err := h.store.GetWebhook(context.Background(), userID, id)
+	if err != nil {
+		webhookFailed(c, lang, err, userID, id)
+		return
+	}
+	hook.Secret = ""
+	c.JSON(http.StatusOK, hook)
+}
+
+// UpdateWebhook godoc
+// @Summary Change a webhook
+// @Description Changes the URL, the event filter or enables/disables the webhook. Deliveries of a disabled webhook wait until it is enabled again.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Param request body UpdateWebhookRequest true "Fields to change"
+// @Success 200 {object} domain.Webhook
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Router /api/v1/webhooks/{id} [patch]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	lang := middleware.Lang(c)
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+		return
+	}
+	if !validRequest(c, lang, req) {
+		return
+	}
+	if req.URL != nil {
+		if err := webhook.ValidateURL(c.Request.Context(), *req.URL); err != nil {
+			problem.Abort(c, lang, http.StatusBadRequest, "api.webhook_url_invalid")
+			return
+		}
+	}
+	userID, id, ok := webhookFromPath(c, lang)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	hook, err := h.store.GetWebhook(ctx, userID, id)
+	if err != nil {
+		webhookFailed(c, lang, err, userID, id)
+		return
+	}
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		hook.Events = eventTypes(req.Events)
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	if err := h.store.UpdateWebhook(ctx, *hook); err != nil {
+		webhookFailed(c, lang, err, userID, id)
+		return
+	}
+
+	slog.Info("Webhook updated", "user_id", userID, "webhook_id", id)
+	hook.Secret = ""
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Pending deliveries and the delivery log are removed as well
+// @Tags webhooks
+// @Param id path int true "Webhook id"
+// @Success 204
+// @Failure 404 {object} problem.Problem
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, id, ok := webhookFromPath(c, lang)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteWebhook(context.Background(), userID, id); err != nil {
+		webhookFailed(c, lang, err, userID, id)
+		return
+	}
+	slog.Info("Webhook deleted", "user_id", userID, "webhook_id", id)
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary Webhook delivery log
+// @Description Latest deliveries of the webhook, newest first: status, attempts, last HTTP status and error
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook id"
+// @Param limit query int false "How many deliveries to return (default 50, max 200)"
+// @Success 200 {array} domain.WebhookDelivery
+// @Failure 404 {object} problem.Problem
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	lang := middleware.Lang(c)
+	userID, id, ok := webhookFromPath(c, lang)
+	if !ok {
+		return
+	}
+	limit := deliveriesLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_limit")
+			return
+		}
+		limit = min(n, maxDeliveriesLimit)
+	}
+
+	ctx := context.Background()
+	// чужой или удалённый webhook — 404, а не пустой журнал
+	if _, err := h.store.GetWebhook(ctx, userID, id); err != nil {
+		webhookFailed(c, lang, err, userID, id)
+		return
+	}
+	deliveries, err := h.store.ListDeliveries(ctx, userID, id, limit)
+	if err != nil {
+		slog.Error("ListDeliveries failed", "error", err, "user_id", userID, "webhook_id", id)
+		problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []domain.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// webhookFailed отвечает на ошибку хранилища; ErrNotFound — своим текстом про webhook
+func webhookFailed(c *gin.Context, lang i18n.Lang, err error, userID, id int64) {
+	status, _, _ := storageError(lang, err, "api.internal")
+	if status == http.StatusNotFound {
+		problem.Abort(c, lang, http.StatusNotFound, "api.webhook_not_found")
+		return
+	}
+	slog.Error("Webhook storage failed", "error", err, "user_id", userID, "webhook_id", id)
+	storageFailed(c, lang, err, "api.internal")
+}
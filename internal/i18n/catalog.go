@@ -0,0 +1,465 @@
+// internal/i18n/catalog.go
+package i18n
+
+var catalog = map[Lang]map[string]string{
+	RU: {
+		// === Бот ===
+		"bot.help": "🏦 *Кэшбэк-трекер*\n\n" +
+			"Команды:\n" +
+			"`/add` — добавить банк: `Сбер: Аптеки 5, Такси 10`\n" +
+			"`/month` — показать кэшбэк за текущий месяц (`/month table` — таблицей)\n" +
+			"`/search_bank Сбер` — найти категории по банку\n" +
+			"`/search_cat Аптеки` — найти банки по категории\n" +
+			"`/history_cat Аптеки 5` — где был кэшбэк от 5% за год\n" +
+			"`/compare 2025-11 2025-12` — что изменилось между месяцами\n" +
+			"`/delete_bank Сбер` — удалить банк\n" +
+			"`/delete_cat Сбер Аптеки` — удалить категорию\n" +
+			"`/export 2025-01 2025-12 xlsx` — выгрузить в csv, xlsx или json\n" +
+			"`/lang en` — сменить язык (ru, en)\n" +
+			"`/remind 25 10:00` — напоминать выбрать категории на следующий месяц\n" +
+			"`/spent Сбер Аптеки 1500` — записать покупку\n" +
+			"`/summary 2025-01` — итоги месяца\n" +
+			"Пришли файл .csv или .json — проверю и покажу изменения, с подписью `/import` — сохраню",
+		"bot.unknown_command": "Неизвестная команда. Напиши /help",
+		"bot.error":           "❌ Ошибка: %s",
+
+		"bot.month.empty":    "📭 Нет данных за %s",
+		"bot.month.title":    "🏦 *Кэшбэк за %s*",
+		"bot.table.bank":     "Банк",
+		"bot.table.category": "Категория",
+
+		"bot.search_bank.need_name": "❌ Укажи название банка",
+		"bot.search_bank.not_found": "📭 Нет кэшбэка по банку *%s*",
+		"bot.search_bank.title":     "🔍 *Категории для %s*",
+		"bot.search_cat.need_name":  "❌ Укажи название категории",
+		"bot.search_cat.not_found":  "📭 Нет кэшбэка по категории *%s*",
+		"bot.search_cat.title":      "🔍 *Банки с кэшбэком по %s*",
+
+		"bot.delete_bank.usage":     "❌ Используй: /delete_bank Банк",
+		"bot.delete_bank.need_name": "укажи название банка",
+		"bot.delete_bank.done":      "✅ Банк удалён",
+		"bot.delete_cat.usage":      "❌ Используй: /delete_cat Банк Категория",
+		"bot.delete_cat.need_args":  "укажи банк и категорию",
+		"bot.delete_cat.done":       "✅ Категория удалена",
+		"bot.delete_bank.not_found": "банка «%s» нет в текущем месяце",
+		"bot.delete_cat.not_found":  "у банка «%s» нет категории «%s» в текущем месяце",
+
+		"bot.add.usage":          "Отправь категории в формате:\nСбер: Аптеки 5, Такси 10",
+		"bot.add.done":           "✅ Сохранено!",
+		"bot.add.format":         "используй формат: Банк: Категория1 5, Категория2 10",
+		"bot.add.empty":          "банк и категории не могут быть пустыми",
+		"bot.add.need_percent":   "категория должна содержать название и процент: %q",
+		"bot.add.bad_percent":    "неверный процент: %q",
+		"bot.add.empty_category": "название категории не может быть пустым",
+		"bot.add.no_categories":  "не найдено ни одной валидной категории",
+
+		"bot.confirm.title":     "⚠️ *%s*: эти изменения перезапишут сохранённые данные",
+		"bot.confirm.inserted":  "+ %s / %s: %.1f%%",
+		"bot.confirm.updated":   "~ %s / %s: %.1f%% → %.1f%%",
+		"bot.confirm.deleted":   "− %s / %s: %.1f%%",
+		"bot.confirm.apply":     "✅ Применить",
+		"bot.confirm.cancel":    "✖️ Отмена",
+		"bot.confirm.cancelled": "Отменено, ничего не изменилось",
+		"bot.confirm.expired":   "Подтверждение устарело, отправь команду ещё раз",
+		"bot.confirm.stale":     "Пока шло подтверждение, месяц изменился. Ничего не записано — отправь команду ещё раз",
+
+		"bot.export.usage":     "используй: /export [2025-01 2025-12] [csv|xlsx|json]",
+		"bot.export.too_many":  "укажи не больше двух месяцев",
+		"bot.export.bad_range": "начальный месяц позже конечного",
+		"bot.export.no_data":   "нет данных за %s — %s",
+
+		"bot.import.too_big":         "файл больше 5 МБ",
+		"bot.import.unsupported":     "поддерживаются только файлы .csv и .json",
+		"bot.import.fetch_failed":    "не удалось получить файл, попробуй ещё раз позже",
+		"bot.import.download_failed": "не удалось скачать файл, попробуй ещё раз позже",
+		"bot.import.invalid":         "❌ В файле есть ошибки, ничего не сохранено (строк: %d)",
+		"bot.import.row_field_error": "- строка %d (%s): %s",
+		"bot.import.row_error":       "- строка %d: %s",
+		"bot.import.more":            "… и ещё %d",
+		"bot.import.dry_title":       "🔎 Проверка импорта (%s), строк: %d",
+		"bot.import.title":           "📥 Импорт (%s), строк: %d",
+		"bot.import.unchanged":       " — без изменений",
+		"bot.import.hint":            "Чтобы применить, отправь файл с подписью /import (или /import replace, чтобы заменить месяцы целиком)",
+
+		"bot.lang.usage": "Используй: /lang ru или /lang en",
+		"bot.lang.done":  "✅ Язык: русский",
+
+		"bot.remind.usage":    "Используй: `/remind 25 10:00 Europe/Moscow` — день месяца, время и часовой пояс (по умолчанию Europe/Moscow), `/remind off` — выключить",
+		"bot.remind.none":     "🔕 Напоминание не настроено",
+		"bot.remind.current":  "⏰ Напоминаю %d числа в %s (%s)",
+		"bot.remind.disabled": "🔕 Напоминание выключено",
+		"bot.remind.saved":    "✅ Следующее напоминание: %s",
+		"bot.remind.message":  "⏰ Пора выбрать категории кэшбэка на *%s* — у некоторых банков выбор доступен только до начала месяца",
+		"bot.remind.copy":     "📋 Скопировать прошлый месяц",
+		"bot.copy.done":       "✅ Скопировал %s → %s, банков: %d",
+		"bot.copy.exists":     "За %s уже есть данные, ничего не копирую",
+		"bot.copy.empty":      "За %s нет данных, копировать нечего",
+
+		"reminder.invalid_day":      "День должен быть от 1 до 31",
+		"reminder.invalid_time":     "Время должно быть в формате ЧЧ:ММ",
+		"reminder.invalid_timezone": "Неизвестный часовой пояс",
+
+		"bot.deadlines.title":   "\n⏳ *Сроки выбора категорий*",
+		"bot.deadlines.filled":  "- %s на %s: до %s — ✅ выбрано",
+		"bot.deadlines.pending": "- %s на %s: до %s — осталось дней: %d",
+		"bot.deadlines.alert":   "⏳ *Скоро закончится выбор категорий*",
+
+		"deadline.invalid_day":    "День должен быть от 1 до 31",
+		"deadline.invalid_offset": "month_offset должен быть 0 (срок в том же месяце) или -1 (в предыдущем)",
+
+		"bot.summary.title":        "📊 *Итоги %s*",
+		"bot.summary.counts":       "Банков: %d, категорий: %d",
+		"bot.summary.empty":        "📭 За %s нет данных",
+		"bot.summary.earned":       "💸 Потрачено: %.2f, кэшбэк: %.2f",
+		"bot.summary.category":     "- %s / %s (%.1f%%): %.2f → %.2f",
+		"bot.summary.no_purchases": "Покупки не записаны — добавляй их через /spent, чтобы видеть заработанный кэшбэк",
+		"bot.summary.unused":       "\n😴 *Не использованы*",
+		"bot.summary.row":          "- %s / %s (%.1f%%)",
+		"bot.summary.compare":      "\n🔁 *По сравнению с %s*",
+		"bot.summary.added":        "+ %s / %s: %.1f%%",
+		"bot.summary.removed":      "− %s / %s: %.1f%%",
+		"bot.summary.changed":      "~ %s / %s: %.1f%% → %.1f%%",
+		"bot.summary.unchanged":    "без изменений",
+		"bot.summary.usage":        "Используй: /summary или /summary 2025-01",
+
+		"bot.suggest.title": "🤔 Возможно, имелось в виду:",
+
+		"bot.history.usage":     "Используй: `/history_cat Аптеки` или `/history_cat Аптеки 5` — банки с кэшбэком от 5%% за последние 12 месяцев",
+		"bot.history.not_found": "📭 За последние 12 месяцев нет кэшбэка по категории *%s*",
+		"bot.history.title":     "🕘 *%s за последние 12 месяцев*",
+		"bot.history.month":     "*%s*: %s",
+
+		"bot.compare.usage":        "Используй: `/compare` (прошлый месяц с текущим) или `/compare 2025-11 2025-12`",
+		"bot.compare.title":        "🔁 *%s → %s*",
+		"bot.compare.unchanged":    "без изменений",
+		"bot.compare.bank_added":   "\n🆕 *%s*",
+		"bot.compare.bank_removed": "\n❌ *%s*",
+		"bot.compare.bank_changed": "\n🏦 *%s*",
+		"bot.compare.added":        "+ %s: %.1f%%",
+		"bot.compare.removed":      "− %s: %.1f%%",
+		"bot.compare.changed":      "~ %s: %.1f%% → %.1f%%",
+
+		"bot.spent.usage":      "Используй: `/spent Сбер Аптеки 1500` или `/spent Тинькофф Black: Аптеки 1500` (можно добавить дату `2025-01-15`)",
+		"bot.spent.done":       "✅ Записал покупку: %s / %s, %.2f",
+		"bot.spent.bad_amount": "❌ Некорректная сумма: %s",
+		"bot.spent.bad_date":   "❌ Дата должна быть в формате YYYY-MM-DD",
+
+		// === API ===
+		"api.invalid_json":            "Некорректный JSON",
+		"api.user_id_missing":         "Не найден user_id",
+		"api.invalid_user_id":         "Некорректный user_id",
+		"api.internal":                "Внутренняя ошибка",
+		"api.save_failed":             "Не удалось сохранить месяц",
+		"api.update_failed":           "Не удалось обновить",
+		"api.update_month_failed":     "Не удалось обновить месяц",
+		"api.month_required":          "Параметр month обязателен в формате YYYY-MM",
+		"api.month_q_required":        "Параметры month и q обязательны",
+		"api.month_format":            "month должен быть в формате YYYY-MM",
+		"api.month_bank_required":     "Параметры month и bank обязательны",
+		"api.month_bank_cat_required": "Параметры month, bank и category обязательны",
+		"api.category_not_found":      "Нет подходящей категории",
+		"api.from_to_required":        "Параметры from и to обязательны",
+		"api.from_to_format":          "from и to должны быть в формате YYYY-MM",
+		"api.from_after_to":           "from не может быть позже to",
+		"api.format_invalid":          "Неподдерживаемый формат %q, ожидается csv, xlsx или json",
+		"api.import_format_invalid":   "Импорт поддерживает только csv и json",
+		"api.mode_invalid":            "Неподдерживаемый режим %q, ожидается merge или replace",
+		"api.file_required":           "Нужно поле формы file",
+		"api.file_unreadable":         "Не удалось прочитать загруженный файл",
+		"api.import_unreadable":       "Не удалось разобрать файл: %s",
+		"api.login.user_id_required":  "user_id обязателен",
+		"api.login.token_failed":      "Не удалось выпустить токен",
+		"api.reminder_not_found":      "Напоминание не настроено",
+		"api.bank_required":           "Параметр bank обязателен",
+		"api.purchase_failed":         "Не удалось сохранить покупку",
+		"api.date_format":             "date должна быть в формате YYYY-MM-DD",
+		"api.year_required":           "Параметр year обязателен, например 2025",
+		"api.min_percent_invalid":     "min_percent должен быть числом от 0 до 100",
+		"api.autocomplete_invalid":    "Нужны параметры type (bank или category) и q",
+		"api.bank_not_in_month":       "Банка %q нет в %s",
+		"api.month_not_found":         "За %s нет данных",
+		"api.category_not_in_bank":    "Категории %q нет у банка %q в %s",
+		"api.not_found":               "Данные не найдены",
+		"api.conflict":                "Данные одновременно изменились в другом запросе, повтори попытку",
+		"api.invalid_data":            "Некорректные данные: %s",
+		"api.idempotency_key_invalid": "Idempotency-Key не длиннее 255 символов",
+		"api.idempotency_mismatch":    "Этот Idempotency-Key уже использован с другим запросом",
+		"api.idempotency_in_progress": "Запрос с этим Idempotency-Key ещё выполняется",
+		"api.request_too_large":       "Тело запроса больше %d МБ",
+		"api.version_mismatch":        "Месяц уже изменён другим запросом: перечитай его и повтори с актуальным ETag",
+		"api.batch_failed":            "Операция %d (%s) не выполнена, ничего не изменено: %s",
+		"api.batch_failed_internal":   "Не удалось выполнить пакет операций, ничего не изменено",
+		"api.invalid_last_event_id":   "Last-Event-ID должен быть числом — id последнего полученного события",
+		"api.webhook_not_found":       "Webhook не найден",
+		"api.webhook_url_invalid":     "Адрес webhook должен быть полным https-адресом без логина и пароля и вести в интернет, а не во внутреннюю сеть",
+		"api.webhook_limit":           "Можно завести не больше %d webhooks",
+		"api.invalid_limit":           "limit должен быть положительным числом",
+
+		"auth.header_required": "Нужен заголовок Authorization",
+		"auth.header_format":   "Некорректный формат заголовка Authorization",
+		"auth.invalid_token":   "Токен недействителен или истёк",
+
+		// === Валидация ===
+		"validation.invalid_input": "некорректные данные: %s",
+		"validation.required":      "%s обязательно",
+		"validation.yearmonth":     "%s должно быть в формате YYYY-MM",
+		"validation.notblank":      "%s не может быть пустым",
+		"validation.min_empty":     "%s не может быть пустым",
+		"validation.min":           "%s слишком короткое",
+		"validation.range":         "%s должно быть от 0 до 100",
+		"validation.max_items":     "в %s не больше %d элементов",
+		"validation.invalid":       "%s некорректно",
+
+		// === Хранилище ===
+		"storage.invalid":                "данные не прошли проверку",
+		"storage.bank_blank":             "название банка не может быть пустым",
+		"storage.bank_no_categories":     "у банка %q должна быть хотя бы одна категория",
+		"storage.category_blank":         "название категории не может быть пустым",
+		"storage.category_blank_in_bank": "название категории у банка %q не может быть пустым",
+		"storage.categories_empty":       "список категорий не может быть пустым",
+		"storage.percent_range":          "процент по категории %q должен быть от 0 до 100",
+		"storage.month_invalid":          "некорректный месяц %q, нужен формат YYYY-MM",
+		"storage.date_invalid":           "некорректная дата %q, нужен формат YYYY-MM-DD",
+		"storage.unknown_operation":      "неизвестная операция %q",
+
+		// === Импорт ===
+		"import.column_count":    "ожидается колонок: %d, в строке: %d",
+		"import.month_invalid":   "некорректный месяц %q, нужен формат YYYY-MM",
+		"import.bank_blank":      "банк не может быть пустым",
+		"import.category_blank":  "категория не может быть пустой",
+		"import.percent_invalid": "некорректный процент %q",
+		"import.percent_range":   "процент %v должен быть от 0 до 100",
+		"import.duplicate":       "повторяет строку %d",
+		"import.month_failed":    "не удалось сохранить месяц",
+	},
+	EN: {
+		// === Bot ===
+		"bot.help": "🏦 *Cashback tracker*\n\n" +
+			"Commands:\n" +
+			"`/add` — add a bank: `Sber: Pharmacies 5, Taxi 10`\n" +
+			"`/month` — show cashback for the current month (`/month table` as a table)\n" +
+			"`/search_bank Sber` — find categories by bank\n" +
+			"`/search_cat Pharmacies` — find banks by category\n" +
+			"`/history_cat Pharmacies 5` — where it was 5% or more over the year\n" +
+			"`/compare 2025-11 2025-12` — what changed between two months\n" +
+			"`/delete_bank Sber` — delete a bank\n" +
+			"`/delete_cat Sber Pharmacies` — delete a category\n" +
+			"`/export 2025-01 2025-12 xlsx` — export to csv, xlsx or json\n" +
+			"`/lang ru` — change language (ru, en)\n" +
+			"`/remind 25 10:00` — remind to pick next month's categories\n" +
+			"`/spent Sber Pharmacies 1500` — log a purchase\n" +
+			"`/summary 2025-01` — month summary\n" +
+			"Send a .csv or .json file to preview the changes, with the caption `/import` to save them",
+		"bot.unknown_command": "Unknown command. Type /help",
+		"bot.error":           "❌ Error: %s",
+
+		"bot.month.empty":    "📭 No data for %s",
+		"bot.month.title":    "🏦 *Cashback for %s*",
+		"bot.table.bank":     "Bank",
+		"bot.table.category": "Category",
+
+		"bot.search_bank.need_name": "❌ Specify a bank name",
+		"bot.search_bank.not_found": "📭 No cashback for bank *%s*",
+		"bot.search_bank.title":     "🔍 *Categories for %s*",
+		"bot.search_cat.need_name":  "❌ Specify a category name",
+		"bot.search_cat.not_found":  "📭 No cashback for category *%s*",
+		"bot.search_cat.title":      "🔍 *Banks with cashback on %s*",
+
+		"bot.delete_bank.usage":     "❌ Usage: /delete_bank Bank",
+		"bot.delete_bank.need_name": "specify a bank name",
+		"bot.delete_bank.done":      "✅ Bank deleted",
+		"bot.delete_cat.usage":      "❌ Usage: /delete_cat Bank Category",
+		"bot.delete_cat.need_args":  "specify a bank and a category",
+		"bot.delete_cat.done":       "✅ Category deleted",
+		"bot.delete_bank.not_found": "bank %q is not in the current month",
+		"bot.delete_cat.not_found":  "bank %q has no category %q in the current month",
+
+		"bot.add.usage":          "Send categories in the format:\nSber: Pharmacies 5, Taxi 10",
+		"bot.add.done":           "✅ Saved!",
+		"bot.add.format":         "use the format: Bank: Category1 5, Category2 10",
+		"bot.add.empty":          "bank and categories must not be empty",
+		"bot.add.need_percent":   "a category must have a name and a percent: %q",
+		"bot.add.bad_percent":    "invalid percent: %q",
+		"bot.add.empty_category": "category name must not be empty",
+		"bot.add.no_categories":  "no valid categories found",
+
+		"bot.confirm.title":     "⚠️ *%s*: these changes will overwrite saved data",
+		"bot.confirm.inserted":  "+ %s / %s: %.1f%%",
+		"bot.confirm.updated":   "~ %s / %s: %.1f%% → %.1f%%",
+		"bot.confirm.deleted":   "− %s / %s: %.1f%%",
+		"bot.confirm.apply":     "✅ Apply",
+		"bot.confirm.cancel":    "✖️ Cancel",
+		"bot.confirm.cancelled": "Cancelled, nothing changed",
+		"bot.confirm.expired":   "This confirmation has expired, send the command again",
+		"bot.confirm.stale":     "The month changed while you were confirming. Nothing was saved, send the command again",
+
+		"bot.export.usage":     "usage: /export [2025-01 2025-12] [csv|xlsx|json]",
+		"bot.export.too_many":  "specify at most two months",
+		"bot.export.bad_range": "the first month is after the last one",
+		"bot.export.no_data":   "no data for %s — %s",
+
+		"bot.import.too_big":         "the file is larger than 5 MB",
+		"bot.import.unsupported":     "only .csv and .json files are supported",
+		"bot.import.fetch_failed":    "cannot get the file, please try again later",
+		"bot.import.download_failed": "cannot download the file, please try again later",
+		"bot.import.invalid":         "❌ The file has errors, nothing was saved (rows: %d)",
+		"bot.import.row_field_error": "- row %d (%s): %s",
+		"bot.import.row_error":       "- row %d: %s",
+		"bot.import.more":            "… and %d more",
+		"bot.import.dry_title":       "🔎 Import preview (%s), rows: %d",
+		"bot.import.title":           "📥 Import (%s), rows: %d",
+		"bot.import.unchanged":       " — no changes",
+		"bot.import.hint":            "To apply, send the file with the caption /import (or /import replace to overwrite whole months)",
+
+		"bot.lang.usage": "Usage: /lang ru or /lang en",
+		"bot.lang.done":  "✅ Language: English",
+
+		"bot.remind.usage":    "Usage: `/remind 25 10:00 Europe/London` — day of month, time and timezone (Europe/Moscow by default), `/remind off` — turn off",
+		"bot.remind.none":     "🔕 No reminder set",
+		"bot.remind.current":  "⏰ Reminding on day %d at %s (%s)",
+		"bot.remind.disabled": "🔕 Reminder turned off",
+		"bot.remind.saved":    "✅ Next reminder: %s",
+		"bot.remind.message":  "⏰ Time to pick cashback categories for *%s* — some banks only accept the choice before the month starts",
+		"bot.remind.copy":     "📋 Copy last month",
+		"bot.copy.done":       "✅ Copied %s → %s, banks: %d",
+		"bot.copy.exists":     "%s already has data, nothing copied",
+		"bot.copy.empty":      "%s has no data, nothing to copy",
+
+		"reminder.invalid_day":      "Day must be between 1 and 31",
+		"reminder.invalid_time":     "Time must be in HH:MM format",
+		"reminder.invalid_timezone": "Unknown timezone",
+
+		"bot.deadlines.title":   "\n⏳ *Category selection deadlines*",
+		"bot.deadlines.filled":  "- %s for %s: until %s — ✅ chosen",
+		"bot.deadlines.pending": "- %s for %s: until %s — days left: %d",
+		"bot.deadlines.alert":   "⏳ *Category selection is closing soon*",
+
+		"deadline.invalid_day":    "Day must be between 1 and 31",
+		"deadline.invalid_offset": "month_offset must be 0 (deadline in the same month) or -1 (in the previous month)",
+
+		"bot.summary.title":        "📊 *Summary for %s*",
+		"bot.summary.counts":       "Banks: %d, categories: %d",
+		"bot.summary.empty":        "📭 No data for %s",
+		"bot.summary.earned":       "💸 Spent: %.2f, cashback: %.2f",
+		"bot.summary.category":     "- %s / %s (%.1f%%): %.2f → %.2f",
+		"bot.summary.no_purchases": "No purchases logged — add them with /spent to see the cashback earned",
+		"bot.summary.unused":       "\n😴 *Not used*",
+		"bot.summary.row":          "- %s / %s (%.1f%%)",
+		"bot.summary.compare":      "\n🔁 *Compared with %s*",
+		"bot.summary.added":        "+ %s / %s: %.1f%%",
+		"bot.summary.removed":      "− %s / %s: %.1f%%",
+		"bot.summary.changed":      "~ %s / %s: %.1f%% → %.1f%%",
+		"bot.summary.unchanged":    "no changes",
+		"bot.summary.usage":        "Usage: /summary or /summary 2025-01",
+
+		"bot.suggest.title": "🤔 Did you mean:",
+
+		"bot.history.usage":     "Usage: `/history_cat Pharmacies` or `/history_cat Pharmacies 5` — banks with at least 5%% over the last 12 months",
+		"bot.history.not_found": "📭 No cashback for category *%s* over the last 12 months",
+		"bot.history.title":     "🕘 *%s over the last 12 months*",
+		"bot.history.month":     "*%s*: %s",
+
+		"bot.compare.usage":        "Usage: `/compare` (last month vs this month) or `/compare 2025-11 2025-12`",
+		"bot.compare.title":        "🔁 *%s → %s*",
+		"bot.compare.unchanged":    "no changes",
+		"bot.compare.bank_added":   "\n🆕 *%s*",
+		"bot.compare.bank_removed": "\n❌ *%s*",
+		"bot.compare.bank_changed": "\n🏦 *%s*",
+		"bot.compare.added":        "+ %s: %.1f%%",
+		"bot.compare.removed":      "− %s: %.1f%%",
+		"bot.compare.changed":      "~ %s: %.1f%% → %.1f%%",
+
+		"bot.spent.usage":      "Usage: `/spent Sber Pharmacies 1500` or `/spent Tinkoff Black: Pharmacies 1500` (optionally add a date `2025-01-15`)",
+		"bot.spent.done":       "✅ Purchase saved: %s / %s, %.2f",
+		"bot.spent.bad_amount": "❌ Invalid amount: %s",
+		"bot.spent.bad_date":   "❌ Date must be in YYYY-MM-DD format",
+
+		// === API ===
+		"api.invalid_json":            "Invalid JSON",
+		"api.user_id_missing":         "user_id missing",
+		"api.invalid_user_id":         "invalid user_id",
+		"api.internal":                "Internal error",
+		"api.save_failed":             "Failed to save month",
+		"api.update_failed":           "Failed to update",
+		"api.update_month_failed":     "Failed to update month",
+		"api.month_required":          "month query param required in YYYY-MM format",
+		"api.month_q_required":        "month and q query params required",
+		"api.month_format":            "month must be in YYYY-MM format",
+		"api.month_bank_required":     "month and bank query params required",
+		"api.month_bank_cat_required": "month, bank, and category query params required",
+		"api.category_not_found":      "No matching category",
+		"api.from_to_required":        "from and to query params required",
+		"api.from_to_format":          "from and to must be in YYYY-MM format",
+		"api.from_after_to":           "from must not be after to",
+		"api.format_invalid":          "unsupported format %q, expected csv, xlsx or json",
+		"api.import_format_invalid":   "import supports only csv and json",
+		"api.mode_invalid":            "unsupported mode %q, expected merge or replace",
+		"api.file_required":           "file form field required",
+		"api.file_unreadable":         "cannot read uploaded file",
+		"api.import_unreadable":       "cannot parse the file: %s",
+		"api.login.user_id_required":  "user_id required",
+		"api.login.token_failed":      "token generation failed",
+		"api.reminder_not_found":      "reminder is not set",
+		"api.bank_required":           "bank query param required",
+		"api.purchase_failed":         "Failed to save purchase",
+		"api.date_format":             "date must be in YYYY-MM-DD format",
+		"api.year_required":           "year query param required, e.g. 2025",
+		"api.min_percent_invalid":     "min_percent must be a number between 0 and 100",
+		"api.autocomplete_invalid":    "type (bank or category) and q query params required",
+		"api.bank_not_in_month":       "bank %q is not in %s",
+		"api.month_not_found":         "no data for %s",
+		"api.category_not_in_bank":    "category %q is not in bank %q in %s",
+		"api.not_found":               "not found",
+		"api.conflict":                "the data was changed by another request at the same time, try again",
+		"api.invalid_data":            "invalid data: %s",
+		"api.idempotency_key_invalid": "Idempotency-Key must be at most 255 characters",
+		"api.idempotency_mismatch":    "this Idempotency-Key was already used with a different request",
+		"api.idempotency_in_progress": "a request with this Idempotency-Key is still in progress",
+		"api.request_too_large":       "the request body is larger than %d MB",
+		"api.version_mismatch":        "the month has been changed by another request: fetch it again and retry with the current ETag",
+		"api.batch_failed":            "operation %d (%s) failed, nothing was changed: %s",
+		"api.batch_failed_internal":   "failed to run the batch, nothing was changed",
+		"api.invalid_last_event_id":   "Last-Event-ID must be a number: the id of the last received event",
+		"api.webhook_not_found":       "webhook not found",
+		"api.webhook_url_invalid":     "the webhook URL must be an absolute https URL without credentials that points to a public address",
+		"api.webhook_limit":           "at most %d webhooks are allowed",
+		"api.invalid_limit":           "limit must be a positive number",
+
+		"auth.header_required": "Authorization header required",
+		"auth.header_format":   "Invalid Authorization header format",
+		"auth.invalid_token":   "Invalid or expired token",
+
+		// === Validation ===
+		"validation.invalid_input": "invalid input: %s",
+		"validation.required":      "%s is required",
+		"validation.yearmonth":     "%s must be in YYYY-MM format",
+		"validation.notblank":      "%s must not be blank",
+		"validation.min_empty":     "%s must not be empty",
+		"validation.min":           "%s is too short",
+		"validation.range":         "%s must be between 0 and 100",
+		"validation.max_items":     "%s must have at most %d items",
+		"validation.invalid":       "%s is invalid",
+
+		// === Storage ===
+		"storage.invalid":                "validation failed",
+		"storage.bank_blank":             "bank name cannot be empty",
+		"storage.bank_no_categories":     "bank %q must have at least one category",
+		"storage.category_blank":         "category name cannot be empty",
+		"storage.category_blank_in_bank": "category name cannot be empty for bank %q",
+		"storage.categories_empty":       "categories list cannot be empty",
+		"storage.percent_range":          "percent must be between 0 and 100 for category %q",
+		"storage.month_invalid":          "invalid month %q, expected YYYY-MM",
+		"storage.date_invalid":           "invalid date %q, expected YYYY-MM-DD",
+		"storage.unknown_operation":      "unknown operation %q",
+
+		// === Import ===
+		"import.column_count":    "expected %d columns, got %d",
+		"import.month_invalid":   "unknown month %q, expected YYYY-MM",
+		"import.bank_blank":      "bank must not be blank",
+		"import.category_blank":  "category must not be blank",
+		"import.percent_invalid": "invalid percent %q",
+		"import.percent_range":   "percent %v must be between 0 and 100",
+		"import.duplicate":       "duplicate of row %d",
+		"import.month_failed":    "failed to save the month",
+	},
+}
@@ -0,0 +1,78 @@
+// internal/i18n/i18n.go
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+type Lang string
+
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+)
+
+// Default — язык бота, если пользователь его не выбрал и Telegram ничего не прислал
+const Default = RU
+
+var supported = []language.Tag{language.Russian, language.English}
+
+var matcher = language.NewMatcher(supported)
+
+// Parse приводит код языка ("en", "en-US", "ru-RU") к поддерживаемому.
+// Второе значение false, если язык не поддерживается.
+func Parse(code string) (Lang, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	switch Lang(code) {
+	case RU, EN:
+		return Lang(code), true
+	}
+	return "", false
+}
+
+// FromTelegram выбирает язык по language_code пользователя Telegram
+func FromTelegram(code string) Lang {
+	if lang, ok := Parse(code); ok {
+		return lang
+	}
+	return Default
+}
+
+// FromAcceptLanguage выбирает язык по заголовку Accept-Language с учётом q-весов
+func FromAcceptLanguage(header string, fallback Lang) Lang {
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return fallback
+	}
+	tag, _, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return fallback
+	}
+	base, _ := tag.Base()
+	if lang, ok := Parse(base.String()); ok {
+		return lang
+	}
+	return fallback
+}
+
+// T возвращает сообщение из каталога, подставляя аргументы через fmt.Sprintf.
+// Если ключа нет в выбранном языке, берётся русский вариант, если нет и его — сам ключ.
+func T(lang Lang, key string, args ...any) string {
+	msg, ok := catalog[lang][key]
+	if !ok {
+		msg, ok = catalog[Default][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
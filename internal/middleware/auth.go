@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"cashback-tracker/internal/auth"
+	"cashback-tracker/internal/problem"
 	"log/slog"
 	"net/http"
 
@@ -23,7 +24,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		slog.Debug("Auth header", "header", authHeader)
 
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			problem.Abort(c, Lang(c), http.StatusUnauthorized, "auth.header_required")
 			return
 		}
 
@@ -31,13 +32,13 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
 			tokenStr = authHeader[7:]
 		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Authorization header format"})
+			problem.Abort(c, Lang(c), http.StatusUnauthorized, "auth.header_format")
 			return
 		}
 
 		userID, err := m.tokenService.ParseToken(tokenStr) // → int64
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			problem.Abort(c, Lang(c), http.StatusUnauthorized, "auth.invalid_token")
 			return
 		}
 
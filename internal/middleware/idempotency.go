@@ -0,0 +1,168 @@
+// internal/middleware/idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/problem"
+	"cashback-tracker/internal/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyHeader — заголовок, по которому повтор запроса получает первый ответ
+const IdempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength ограничивает ключ: клиенты обычно шлют UUID
+const maxIdempotencyKeyLength = 255
+
+// Idempotency сохраняет первый ответ на изменяющий запрос с Idempotency-Key и отдаёт его
+// же на повторы с тем же ключом от того же пользователя на тот же маршрут в течение ttl.
+// Повтор с другим телом или параметрами получает 422, повтор во время выполнения — 409.
+// Ответы 5xx не сохраняются: после сбоя запрос можно повторить по-настоящему.
+// Тело читается целиком до обработчика, поэтому оно ограничено maxBody — самым
+// большим телом, которое принимает какой-либо маршрут; больше — 413.
+// Ставится после RequireAuth — нужен user_id.
+func Idempotency(store storage.IdempotencyStorage, ttl time.Duration, maxBody int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		lang := Lang(c)
+		if len(key) > maxIdempotencyKeyLength {
+			problem.Abort(c, lang, http.StatusBadRequest, "api.idempotency_key_invalid")
+			return
+		}
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				problem.Abort(c, lang, http.StatusRequestEntityTooLarge, "api.request_too_large", maxBody>>20)
+				return
+			}
+			problem.Abort(c, lang, http.StatusBadRequest, "api.invalid_json")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := domain.IdempotencyRecord{
+			UserID:      userID.(int64),
+			Key:         key,
+			Route:       c.Request.Method + " " + c.FullPath(),
+			RequestHash: requestHash(c.Request.URL.RawQuery, body),
+		}
+		ctx := context.Background()
+		existing, err := store.ReserveIdempotencyKey(ctx, rec, time.Now().Add(-ttl))
+		if err != nil {
+			slog.Error("Не удалось занять Idempotency-Key", "error", err, "user_id", rec.UserID)
+			problem.Abort(c, lang, http.StatusInternalServerError, "api.internal")
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.RequestHash != rec.RequestHash:
+				problem.Abort(c, lang, http.StatusUnprocessableEntity, "api.idempotency_mismatch")
+			case existing.Status == 0:
+				problem.Abort(c, lang, http.StatusConflict, "api.idempotency_in_progress")
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.Status, existing.ContentType, existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		// ключ освобождается и при панике в обработчике, иначе повторы сутки получали бы 409
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.ReleaseIdempotencyKey(ctx, rec.UserID, rec.Key, rec.Route); err != nil {
+				slog.Error("Не удалось освободить Idempotency-Key", "error", err, "user_id", rec.UserID)
+			}
+		}()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+		rec.Status = w.Status()
+		rec.ContentType = w.Header().Get("Content-Type")
+		rec.Body = w.body.Bytes()
+		if replay, ok := c.Get(replayBodyKey); ok {
+			rec.Body = replay.([]byte)
+		}
+		if err := store.CompleteIdempotencyKey(ctx, rec); err != nil {
+			slog.Error("Не удалось сохранить ответ для Idempotency-Key", "error", err, "user_id", rec.UserID)
+		}
+	}
+}
+
+const replayBodyKey = "idempotency_replay_body"
+
+// SetReplayBody задаёт, что сохранить для повторов вместо отправленного тела ответа.
+// Нужен, когда ответ содержит то, что нельзя хранить в idempotency_keys, например
+// секрет webhook: повтор получит v без него.
+func SetReplayBody(c *gin.Context, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.Set(replayBodyKey, body)
+	return nil
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestHash — отпечаток запроса: параметры строки запроса и тело
+func requestHash(query string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(query))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter копирует тело ответа, чтобы его можно было сохранить
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,26 @@
+// internal/middleware/language.go
+package middleware
+
+import (
+	"cashback-tracker/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Language выбирает язык ответа по Accept-Language; без заголовка API отвечает по-английски
+func Language() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("lang", i18n.FromAcceptLanguage(c.GetHeader("Accept-Language"), i18n.EN))
+		c.Next()
+	}
+}
+
+// Lang возвращает язык, выбранный middleware Language
+func Lang(c *gin.Context) i18n.Lang {
+	if v, ok := c.Get("lang"); ok {
+		if lang, ok := v.(i18n.Lang); ok {
+			return lang
+		}
+	}
+	return i18n.EN
+}
@@ -0,0 +1,443 @@
+// internal/openapi/openapi.go
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version — версия формата OpenAPI документа
+const Version = "3.0.3"
+
+// Document — OpenAPI-документ. Пути добавляются через Add, схемы тел запросов
+// и ответов выводятся из Go-типов (Schema), поэтому DTO и документ не расходятся.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []SecurityRequirement            `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement — имя схемы из Components.SecuritySchemes → области доступа
+type SecurityRequirement map[string][]string
+
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security — nil: как у документа; Public() — операция без авторизации
+	Security *[]SecurityRequirement `json:"security,omitempty"`
+}
+
+// Public — Operation.Security для операций, доступных без авторизации
+func Public() *[]SecurityRequirement {
+	return &[]SecurityRequirement{}
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]map[string]*Operation),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+}
+
+// UseBearerAuth требует JWT в Authorization у всех операций, кроме явно открытых
+func (d *Document) UseBearerAuth() {
+	d.Components.SecuritySchemes = map[string]*SecurityScheme{
+		"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+	}
+	d.Security = []SecurityRequirement{{"bearerAuth": {}}}
+}
+
+// Add описывает операцию. path — в записи OpenAPI: /api/v2/months/{month}
+func (d *Document) Add(method, path string, op Operation) {
+	if d.Paths[path] == nil {
+		d.Paths[path] = make(map[string]*Operation)
+	}
+	d.Paths[path][strings.ToLower(method)] = &op
+}
+
+// --- Параметры и ответы ---
+
+func PathParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: schema}
+}
+
+func QueryParam(name, description string, required bool, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Required: required, Schema: schema}
+}
+
+func HeaderParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: schema}
+}
+
+func String() *Schema  { return &Schema{Type: "string"} }
+func Integer() *Schema { return &Schema{Type: "integer"} }
+func Number() *Schema  { return &Schema{Type: "number"} }
+func Boolean() *Schema { return &Schema{Type: "boolean"} }
+
+// Enum — строка с одним из значений
+func Enum(values ...string) *Schema {
+	s := String()
+	for _, v := range values {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+// monthPattern — формат месяца YYYY-MM, как у валидатора yearmonth
+const monthPattern = `^\d{4}-(0[1-9]|1[0-2])$`
+
+// Month — строка месяца в формате YYYY-MM
+func Month() *Schema {
+	return &Schema{Type: "string", Pattern: monthPattern}
+}
+
+// Body — тело запроса в JSON со схемой из типа значения v
+func (d *Document) Body(v any) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]*MediaType{
+		"application/json": {Schema: d.Schema(v)},
+	}}
+}
+
+// JSON — ответ с телом в JSON; v = nil — без тела
+func (d *Document) JSON(description string, v any) *Response {
+	if v == nil {
+		return &Response{Description: description}
+	}
+	return d.Content(description, "application/json", v)
+}
+
+// Content — ответ с телом типа contentType со схемой из типа значения v
+func (d *Document) Content(description, contentType string, v any) *Response {
+	return &Response{Description: description, Content: map[string]*MediaType{
+		contentType: {Schema: d.Schema(v)},
+	}}
+}
+
+// WithHeader добавляет к ответу заголовок
+func (r *Response) WithHeader(name, description string) *Response {
+	if r.Headers == nil {
+		r.Headers = make(map[string]*Header)
+	}
+	r.Headers[name] = &Header{Description: description, Schema: String()}
+	return r
+}
+
+// --- Схемы из Go-типов ---
+
+// Schema выводит схему из типа значения v. Именованные структуры попадают в
+// components/schemas под именем пакет.Тип и подставляются ссылкой; правила
+// из тегов validate (required, oneof, gte, min, dive, yearmonth…) переносятся в схему.
+func (d *Document) Schema(v any) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return String()
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s := Integer()
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			s.Format = "int64"
+		}
+		return s
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// заглушка до разбора полей — на случай типов, ссылающихся на себя
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	// interface{} и всё прочее — любое значение
+	return &Schema{}
+}
+
+// schemaName — имя схемы как у swag: последний элемент пути пакета и имя типа
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndexByte(pkg, '/'); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	return pkg + "." + t.Name()
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.addFields(s, t)
+	if len(s.Required) > 0 {
+		sort.Strings(s.Required)
+	}
+	return s
+}
+
+func (d *Document) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// встроенная структура без json-имени раскрывается, как это делает encoding/json
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := d.schemaOf(f.Type)
+		required := applyValidate(prop, f.Tag.Get("validate"))
+		if f.Type.Kind() == reflect.Pointer && prop.Ref == "" {
+			prop.Nullable = true
+		}
+		s.Properties[name] = prop
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// applyValidate переносит правила тега validate в схему поля; true — поле обязательно.
+// Правила после dive относятся к элементам среза.
+func applyValidate(s *Schema, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+	own, elem, hasDive := strings.Cut(tag, ",dive")
+	if hasDive && s.Items != nil {
+		// у элемента-структуры правила уже в её схеме; ссылку не трогаем
+		if s.Items.Ref == "" {
+			applyValidate(s.Items, strings.TrimPrefix(elem, ","))
+		}
+	}
+	// ссылки в OpenAPI 3.0 не дополняются соседними ключами
+	if s.Ref != "" {
+		return strings.Contains(","+own+",", ",required,")
+	}
+
+	for _, rule := range strings.Split(own, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		case "yearmonth":
+			s.Pattern = monthPattern
+		case "notblank":
+			s.Pattern = `\S`
+		case "url":
+			s.Format = "uri"
+		case "gte", "gt", "lte", "lt", "min", "max":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			applyBound(s, name, n)
+		}
+	}
+	return required
+}
+
+func applyBound(s *Schema, rule string, n float64) {
+	lower := rule == "gte" || rule == "gt" || rule == "min"
+	switch s.Type {
+	case "array":
+		v := int(n)
+		if lower {
+			s.MinItems = &v
+		} else {
+			s.MaxItems = &v
+		}
+	case "string":
+		v := int(n)
+		if lower {
+			s.MinLength = &v
+		} else {
+			s.MaxLength = &v
+		}
+	default:
+		if lower {
+			s.Minimum = &n
+			s.ExclusiveMinimum = rule == "gt"
+		} else {
+			s.Maximum = &n
+			s.ExclusiveMaximum = rule == "lt"
+		}
+	}
+}
+
+// --- Сверка с маршрутами ---
+
+// Route — маршрут роутера: метод и путь в записи gin (/months/:month)
+type Route struct {
+	Method string
+	Path   string
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Check сверяет документ с маршрутами: ошибка перечисляет маршруты без описания
+// и описания без маршрута. Пути из ignore (служебные, сама документация) не проверяются.
+func (d *Document) Check(routes []Route, ignore ...string) error {
+	skip := make(map[string]bool, len(ignore))
+	for _, p := range ignore {
+		skip[p] = true
+	}
+
+	routed := make(map[string]bool)
+	var problems []string
+	for _, r := range routes {
+		if skip[r.Path] {
+			continue
+		}
+		path := ginParam.ReplaceAllString(r.Path, "{$1}")
+		key := strings.ToUpper(r.Method) + " " + path
+		routed[key] = true
+		if d.Paths[path][strings.ToLower(r.Method)] == nil {
+			problems = append(problems, "not documented: "+key)
+		}
+	}
+	for path, ops := range d.Paths {
+		for method := range ops {
+			key := strings.ToUpper(method) + " " + path
+			if !routed[key] {
+				problems = append(problems, "not routed: "+key)
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("openapi spec and routes disagree:\n  %s", strings.Join(problems, "\n  "))
+}
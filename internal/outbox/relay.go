@@ -0,0 +1,109 @@
+// internal/outbox/relay.go
+package outbox
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// lease — сколько событие считается занятым; если процесс упал или подписчик
+	// вернул ошибку, событие опубликуется снова после истечения
+	lease     = 30 * time.Second
+	batchSize = 100
+)
+
+// Handler получает событие. Ошибка оставляет событие неопубликованным: его получат
+// все подписчики ещё раз, поэтому обработчик должен переносить повторы (по Event.ID).
+type Handler func(ctx context.Context, e domain.Event) error
+
+// Relay публикует события из outbox подписчикам процесса. Доставка «хотя бы раз»:
+// событие помечается опубликованным только после того, как все подписчики его приняли.
+type Relay struct {
+	store    storage.OutboxStorage
+	interval time.Duration
+	handlers []namedHandler
+}
+
+type namedHandler struct {
+	name string
+	fn   Handler
+}
+
+func NewRelay(store storage.OutboxStorage, interval time.Duration) *Relay {
+	return &Relay{store: store, interval: interval}
+}
+
+// Subscribe добавляет подписчика; вызывать до Run
+func (r *Relay) Subscribe(name string, fn Handler) {
+	r.handlers = append(r.handlers, namedHandler{name: name, fn: fn})
+}
+
+// Run работает до отмены ctx
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		// полная пачка — скорее всего, есть ещё: забираем, не дожидаясь тика
+		for ctx.Err() == nil && r.tick(ctx) == batchSize {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick публикует одну пачку и возвращает её размер
+func (r *Relay) tick(ctx context.Context) int {
+	now := time.Now()
+	events, err := r.store.ClaimOutboxEvents(ctx, now, now.Add(lease), batchSize)
+	if err != nil {
+		slog.Error("Не удалось получить события outbox", "error", err)
+		return 0
+	}
+
+	published := make([]int64, 0, len(events))
+	for _, e := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := r.publish(ctx, e); err != nil {
+			// блокировка истечёт через lease, и событие уйдёт ещё раз
+			slog.Error("Событие outbox не опубликовано", "error", err, "event_id", e.ID, "type", e.Type, "user_id", e.UserID)
+			continue
+		}
+		published = append(published, e.ID)
+	}
+	if len(published) > 0 {
+		if err := r.store.MarkOutboxPublished(ctx, published); err != nil {
+			slog.Error("Не удалось отметить события outbox", "error", err, "count", len(published))
+		}
+	}
+	return len(events)
+}
+
+func (r *Relay) publish(ctx context.Context, e domain.Event) error {
+	var errs []error
+	for _, h := range r.handlers {
+		if err := r.call(ctx, h, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Relay) call(ctx context.Context, h namedHandler, e domain.Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h.fn(ctx, e)
+}
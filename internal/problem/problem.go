@@ -0,0 +1,75 @@
+// internal/problem/problem.go
+package problem
+
+import (
+	"cashback-tracker/internal/i18n"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType — тип ответа об ошибке по RFC 7807
+const ContentType = "application/problem+json"
+
+// CodeValidation — код ответа, в котором Errors перечисляет неверные поля
+const CodeValidation = "validation_failed"
+
+// Problem — тело ответа об ошибке (RFC 7807). Code — стабильный машиночитаемый
+// код, на него и стоит завязываться клиентам; Detail переведён на язык запроса.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError — ошибка одного поля запроса; Field — путь в JSON, например banks[0].categories[1].percent
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Code выводит код ошибки из ключа каталога: "api.month_required" → "month_required",
+// "auth.invalid_token" → "auth_invalid_token". Ключи каталога не меняются, поэтому и коды стабильны.
+func Code(key string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(key, "api."), ".", "_")
+}
+
+// Abort отвечает ошибкой с текстом из каталога и прерывает цепочку обработчиков
+func Abort(c *gin.Context, lang i18n.Lang, status int, key string, args ...any) {
+	Write(c, Problem{Status: status, Code: Code(key), Detail: i18n.T(lang, key, args...)})
+}
+
+// Invalid отвечает 400 со списком неверных полей
+func Invalid(c *gin.Context, lang i18n.Lang, fields []FieldError) {
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Message
+	}
+	Write(c, Problem{
+		Status: http.StatusBadRequest,
+		Code:   CodeValidation,
+		Detail: i18n.T(lang, "validation.invalid_input", strings.Join(messages, "; ")),
+		Errors: fields,
+	})
+}
+
+// Write дополняет p стандартными полями и отправляет его
+func Write(c *gin.Context, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
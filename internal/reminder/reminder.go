@@ -0,0 +1,156 @@
+// internal/reminder/reminder.go
+package reminder
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// база часовых поясов на случай, если в контейнере нет /usr/share/zoneinfo
+	_ "time/tzdata"
+)
+
+// JobKind — вид задачи планировщика для напоминаний
+const JobKind = "month_reminder"
+
+// DefaultTimezone используется, если пользователь не указал часовой пояс
+const DefaultTimezone = "Europe/Moscow"
+
+var (
+	ErrInvalidDay      = errors.New("day must be between 1 and 31")
+	ErrInvalidTime     = errors.New("time must be in HH:MM format")
+	ErrInvalidTimezone = errors.New("unknown timezone")
+)
+
+type Store interface {
+	storage.ReminderStorage
+	storage.JobStorage
+}
+
+// Service хранит настройки напоминаний и держит задачу планировщика в согласии с ними
+type Service struct {
+	store Store
+}
+
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Get возвращает настройки или nil, если напоминание не настроено
+func (s *Service) Get(ctx context.Context, userID int64) (*domain.ReminderSettings, error) {
+	return s.store.GetReminder(ctx, userID)
+}
+
+// Save проверяет и сохраняет настройки. Для включённого напоминания
+// возвращает время ближайшего срабатывания, для выключенного — нулевое время.
+func (s *Service) Save(ctx context.Context, settings domain.ReminderSettings) (time.Time, error) {
+	if settings.Timezone == "" {
+		settings.Timezone = DefaultTimezone
+	}
+	if err := Validate(settings); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.store.SaveReminder(ctx, settings); err != nil {
+		return time.Time{}, err
+	}
+
+	if !settings.Enabled {
+		return time.Time{}, s.store.CancelJob(ctx, JobKind, settings.UserID)
+	}
+	next, err := NextRun(settings, time.Now())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return next, s.store.ScheduleJob(ctx, JobKind, settings.UserID, next)
+}
+
+// Disable выключает напоминание, сохраняя день и время
+func (s *Service) Disable(ctx context.Context, userID int64) error {
+	settings, err := s.store.GetReminder(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if settings != nil {
+		settings.Enabled = false
+		if err := s.store.SaveReminder(ctx, *settings); err != nil {
+			return err
+		}
+	}
+	return s.store.CancelJob(ctx, JobKind, userID)
+}
+
+func (s *Service) Delete(ctx context.Context, userID int64) error {
+	if err := s.store.CancelJob(ctx, JobKind, userID); err != nil {
+		return err
+	}
+	return s.store.DeleteReminder(ctx, userID)
+}
+
+func Validate(settings domain.ReminderSettings) error {
+	if settings.Day < 1 || settings.Day > 31 {
+		return ErrInvalidDay
+	}
+	if _, err := time.Parse("15:04", settings.Time); err != nil {
+		return ErrInvalidTime
+	}
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// NextRun — ближайший момент срабатывания строго после after.
+// Если в месяце меньше дней, чем Day, напоминание приходит в последний день.
+func NextRun(settings domain.ReminderSettings, after time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidTimezone, settings.Timezone)
+	}
+	clock, err := time.Parse("15:04", settings.Time)
+	if err != nil {
+		return time.Time{}, ErrInvalidTime
+	}
+
+	local := after.In(loc)
+	for i := 0; i < 2; i++ {
+		first := time.Date(local.Year(), local.Month()+time.Month(i), 1, 0, 0, 0, 0, loc)
+		day := min(settings.Day, daysIn(first))
+		run := time.Date(first.Year(), first.Month(), day, clock.Hour(), clock.Minute(), 0, 0, loc)
+		if run.After(after) {
+			return run, nil
+		}
+	}
+	// сюда не попадаем: срабатывание в следующем месяце всегда позже after
+	return time.Time{}, fmt.Errorf("no run time after %s", after)
+}
+
+// UpcomingMonth — месяц (YYYY-MM), о котором напоминание в момент at
+func UpcomingMonth(settings domain.ReminderSettings, at time.Time) string {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := at.In(loc)
+	return time.Date(local.Year(), local.Month()+1, 1, 0, 0, 0, 0, loc).Format("2006-01")
+}
+
+func daysIn(first time.Time) int {
+	return first.AddDate(0, 1, -1).Day()
+}
+
+// ErrorKey — ключ каталога сообщений для ошибки валидации или "", если ошибка не из этого пакета
+func ErrorKey(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidDay):
+		return "reminder.invalid_day"
+	case errors.Is(err, ErrInvalidTime):
+		return "reminder.invalid_time"
+	case errors.Is(err, ErrInvalidTimezone):
+		return "reminder.invalid_timezone"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,106 @@
+// internal/scheduler/scheduler.go
+package scheduler
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var errPanic = errors.New("job panicked")
+
+const (
+	// lease — сколько задача считается занятой; если процесс упал, её подхватят после истечения
+	lease     = 5 * time.Minute
+	batchSize = 50
+
+	baseRetryDelay = time.Minute
+	maxRetryDelay  = time.Hour
+)
+
+// HandlerFunc выполняет задачу и возвращает время следующего запуска;
+// нулевое время — задача больше не нужна и удаляется
+type HandlerFunc func(ctx context.Context, job domain.Job) (time.Time, error)
+
+// Scheduler периодически забирает созревшие задачи из хранилища и выполняет их.
+// Задачи хранятся в БД, поэтому переживают перезапуск процесса.
+type Scheduler struct {
+	jobs     storage.JobStorage
+	interval time.Duration
+	handlers map[string]HandlerFunc
+}
+
+func New(jobs storage.JobStorage, interval time.Duration) *Scheduler {
+	return &Scheduler{jobs: jobs, interval: interval, handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle регистрирует обработчик для вида задач; вызывать до Run
+func (s *Scheduler) Handle(kind string, fn HandlerFunc) {
+	s.handlers[kind] = fn
+}
+
+// Run работает до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	now := time.Now()
+	jobs, err := s.jobs.ClaimDueJobs(ctx, now, now.Add(lease), batchSize)
+	if err != nil {
+		slog.Error("Не удалось получить задачи планировщика", "error", err)
+		return
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			// незавершённые задачи освободятся по истечении lease
+			return
+		}
+		s.run(ctx, job)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job domain.Job) {
+	handler, ok := s.handlers[job.Kind]
+	if !ok {
+		slog.Warn("Нет обработчика для задачи", "kind", job.Kind, "job_id", job.ID)
+		return
+	}
+
+	next, err := s.call(ctx, handler, job)
+	switch {
+	case err != nil:
+		delay := min(baseRetryDelay<<min(job.Attempts, 10), maxRetryDelay)
+		slog.Error("Задача завершилась с ошибкой", "error", err, "kind", job.Kind, "user_id", job.UserID, "retry_in", delay)
+		err = s.jobs.RescheduleJob(ctx, job.ID, time.Now().Add(delay), job.Attempts+1, err.Error())
+	case next.IsZero():
+		err = s.jobs.DeleteJob(ctx, job.ID)
+	default:
+		err = s.jobs.RescheduleJob(ctx, job.ID, next, 0, "")
+	}
+	if err != nil {
+		slog.Error("Не удалось обновить задачу", "error", err, "job_id", job.ID)
+	}
+}
+
+func (s *Scheduler) call(ctx context.Context, handler HandlerFunc, job domain.Job) (next time.Time, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Паника в задаче планировщика", "panic", r, "kind", job.Kind, "job_id", job.ID)
+			err = errPanic
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,47 @@
+// internal/storage/errors.go
+package storage
+
+import (
+	"cashback-tracker/internal/i18n"
+	"errors"
+)
+
+// Ошибки хранилища, по которым обработчики выбирают HTTP-статус.
+// Реализации оборачивают их: fmt.Errorf("%w: ...", storage.ErrNotFound).
+var (
+	// ErrNotFound — месяца, банка или категории нет
+	ErrNotFound = errors.New("not found")
+	// ErrConflict — запись не прошла из-за параллельного изменения тех же данных
+	ErrConflict = errors.New("conflict")
+	// ErrValidation — данные не прошли проверку хранилища; подробности — в ValidationError
+	ErrValidation = errors.New("validation failed")
+	// ErrStaleVersion — месяц изменился после того, как клиент его прочитал (см. WithVersion)
+	ErrStaleVersion = errors.New("stale version")
+	// ErrLimitExceeded — у пользователя уже столько записей, сколько можно
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+// ValidationError — ErrValidation с ключом каталога i18n: обработчик переводит
+// его на язык запроса (Text), а errors.Is(err, ErrValidation) для неё истинно
+type ValidationError struct {
+	Key  string
+	Args []any
+}
+
+// Invalid возвращает ErrValidation с текстом из каталога по key
+func Invalid(key string, args ...any) error {
+	return &ValidationError{Key: key, Args: args}
+}
+
+func (e *ValidationError) Error() string {
+	return ErrValidation.Error() + ": " + e.Text(i18n.EN)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Text — описание ошибки на языке lang
+func (e *ValidationError) Text(lang i18n.Lang) string {
+	return i18n.T(lang, e.Key, e.Args...)
+}
@@ -3,20 +3,37 @@ package postgres
 
 import (
 	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/fuzzy"
+	"cashback-tracker/internal/storage"
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"log/slog"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// dbtx — общее у пула и транзакции. Внутри WithinTx методы Storage работают
+// поверх одной транзакции, а их собственные Begin становятся точками сохранения.
+type dbtx interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type Storage struct {
-	db *pgxpool.Pool
+	db dbtx
+
+	// trgm — установлен ли pg_trgm; проверяется один раз при первой подсказке
+	trgmOnce sync.Once
+	trgm     bool
 }
 
 func NewStorage(db *pgxpool.Pool) *Storage {
@@ -44,6 +61,23 @@ func sanitizeString(s string) string {
 	return clean
 }
 
+// WithinTx выполняет fn в одной транзакции: ошибка fn откатывает всё, что сделано через tx
+func (s *Storage) WithinTx(ctx context.Context, fn func(tx storage.CashbackStorage) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&Storage{db: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
 // === BankStorage ===
 
 func (s *Storage) CreateIfNotExists(ctx context.Context, name string) (int, error) {
@@ -102,46 +136,115 @@ func (s *Storage) FindCategoryByName(ctx context.Context, name string) (*domain.
 
 // === CashbackStorage ===
 
-func (s *Storage) SaveMonth(ctx context.Context, userID int64, monthStr string, bankCategories []domain.BankWithCategories) error {
+// lockMonth блокирует строку месяца до конца транзакции и сверяет её версию с
+// ожидаемой (storage.WithVersion). monthID = 0 — месяца нет, его версия считается 0.
+func lockMonth(ctx context.Context, tx pgx.Tx, userID int64, monthTime time.Time) (monthID, version int, err error) {
+	err = tx.QueryRow(ctx, `
+		SELECT id, version FROM cashback_months
+		WHERE user_id = $1 AND month = $2
+		FOR UPDATE
+	`, userID, monthTime).Scan(&monthID, &version)
+	if err != nil && err != pgx.ErrNoRows {
+		return 0, 0, fmt.Errorf("lock month: %w", err)
+	}
+	if expected, ok := storage.ExpectedVersion(ctx); ok && expected != version {
+		return 0, 0, fmt.Errorf("%w: month version is %d, expected %d", storage.ErrStaleVersion, version, expected)
+	}
+	return monthID, version, nil
+}
+
+// bumpVersion выдаёт месяцу новую версию из общей последовательности: версии только
+// растут и не повторяются, даже если месяц удалили и создали заново
+func bumpVersion(ctx context.Context, tx pgx.Tx, monthID int) (int, error) {
+	var version int
+	err := tx.QueryRow(ctx, `
+		UPDATE cashback_months SET version = nextval('cashback_month_version_seq')
+		WHERE id = $1
+		RETURNING version
+	`, monthID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("bump month version: %w", err)
+	}
+	return version, nil
+}
+
+// recordEvent пишет событие в outbox той же транзакцией, что и само изменение:
+// событие появится, только если изменение зафиксировано (см. outbox.Relay)
+func recordEvent(ctx context.Context, tx pgx.Tx, e domain.Event) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO outbox_events (user_id, type, month, bank, category, version)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, e.UserID, string(e.Type), e.Month, e.Bank, e.Category, e.Version)
+	if err != nil {
+		return fmt.Errorf("record outbox event: %w", err)
+	}
+	return nil
+}
+
+// readMonth читает месяц транзакцией записи: Before и After в domain.MonthWrite
+// видят ровно то, что было до записи и что она оставила
+func readMonth(ctx context.Context, tx pgx.Tx, userID int64, monthStr string) (*domain.CashbackMonth, error) {
+	return (&Storage{db: tx}).GetMonth(ctx, userID, monthStr)
+}
+
+// conflict помечает нарушение уникальности (параллельная запись того же месяца) как storage.ErrConflict
+func conflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("%w: %w", storage.ErrConflict, err)
+	}
+	return err
+}
+
+func (s *Storage) SaveMonth(ctx context.Context, userID int64, monthStr string, bankCategories []domain.BankWithCategories) (*domain.MonthWrite, error) {
 	for _, bc := range bankCategories {
 		if strings.TrimSpace(bc.Bank.Name) == "" {
-			return fmt.Errorf("bank name cannot be empty")
+			return nil, storage.Invalid("storage.bank_blank")
 		}
 		if len(bc.Categories) == 0 {
-			return fmt.Errorf("bank %q must have at least one category", bc.Bank.Name)
+			return nil, storage.Invalid("storage.bank_no_categories", bc.Bank.Name)
 		}
 		for _, cc := range bc.Categories {
 			if strings.TrimSpace(cc.Category.Name) == "" {
-				return fmt.Errorf("category name cannot be empty for bank %q", bc.Bank.Name)
+				return nil, storage.Invalid("storage.category_blank_in_bank", bc.Bank.Name)
 			}
 			if cc.Percent < 0 || cc.Percent > 100 {
-				return fmt.Errorf("percent must be between 0 and 100 for category %q", cc.Category.Name)
+				return nil, storage.Invalid("storage.percent_range", cc.Category.Name)
 			}
 		}
 	}
 
 	monthTime, err := time.Parse("2006-01", monthStr)
 	if err != nil {
-		return fmt.Errorf("invalid month format, expected YYYY-MM: %w", err)
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
 	}
 
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	if _, _, err := lockMonth(ctx, tx, userID, monthTime); err != nil {
+		return nil, err
+	}
+	before, err := readMonth(ctx, tx, userID, monthStr)
+	if err != nil {
+		return nil, err
+	}
+
 	_, err = tx.Exec(ctx, "DELETE FROM cashback_months WHERE user_id = $1 AND month = $2", userID, monthTime)
 	if err != nil {
-		return fmt.Errorf("clear old month: %w", err)
+		return nil, fmt.Errorf("clear old month: %w", err)
 	}
 
-	var monthID int
+	// месяц пересоздаётся целиком и получает новую версию из последовательности (DEFAULT)
+	var monthID, version int
 	err = tx.QueryRow(ctx, `
-		INSERT INTO cashback_months (user_id, month) VALUES ($1, $2) RETURNING id
-	`, userID, monthTime).Scan(&monthID)
+		INSERT INTO cashback_months (user_id, month) VALUES ($1, $2) RETURNING id, version
+	`, userID, monthTime).Scan(&monthID, &version)
 	if err != nil {
-		return fmt.Errorf("insert cashback_month: %w", err)
+		return nil, fmt.Errorf("insert cashback_month: %w", conflict(err))
 	}
 
 	createBankInTx := func(name string) (int, error) {
@@ -167,13 +270,13 @@ func (s *Storage) SaveMonth(ctx context.Context, userID int64, monthStr string,
 	for _, bc := range bankCategories {
 		bankID, err := createBankInTx(bc.Bank.Name)
 		if err != nil {
-			return fmt.Errorf("create bank %q: %w", bc.Bank.Name, err)
+			return nil, fmt.Errorf("create bank %q: %w", bc.Bank.Name, err)
 		}
 
 		for _, cc := range bc.Categories {
 			categoryID, err := createCategoryInTx(cc.Category.Name)
 			if err != nil {
-				return fmt.Errorf("create category %q: %w", cc.Category.Name, err)
+				return nil, fmt.Errorf("create category %q: %w", cc.Category.Name, err)
 			}
 
 			_, err = tx.Exec(ctx, `
@@ -183,30 +286,37 @@ func (s *Storage) SaveMonth(ctx context.Context, userID int64, monthStr string,
 				DO UPDATE SET percent = EXCLUDED.percent
 			`, monthID, bankID, categoryID, cc.Percent)
 			if err != nil {
-				return fmt.Errorf("link bank-category: %w", err)
+				return nil, fmt.Errorf("link bank-category: %w", err)
 			}
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
+	if err := recordEvent(ctx, tx, domain.Event{Type: domain.EventMonthSaved, UserID: userID, Month: monthStr, Version: version}); err != nil {
+		return nil, err
 	}
 
+	after, err := readMonth(ctx, tx, userID, monthStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
 	slog.Debug("SaveMonth completed", "user_id", userID, "month", monthStr)
-	return nil
+	return &domain.MonthWrite{Before: before, After: after}, nil
 }
 
 func (s *Storage) GetMonth(ctx context.Context, userID int64, monthStr string) (*domain.CashbackMonth, error) {
 	monthTime, err := time.Parse("2006-01", monthStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid month format: %w", err)
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
 	}
 
-	var monthID int
+	var monthID, version int
 	err = s.db.QueryRow(ctx, `
-		SELECT id FROM cashback_months
+		SELECT id, version FROM cashback_months
 		WHERE user_id = $1 AND month = $2
-	`, userID, monthTime).Scan(&monthID)
+	`, userID, monthTime).Scan(&monthID, &version)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -261,16 +371,17 @@ func (s *Storage) GetMonth(ctx context.Context, userID int64, monthStr string) (
 	}
 
 	return &domain.CashbackMonth{
-		Month:  monthStr,
-		UserID: userID,
-		Banks:  banks,
+		Month:   monthStr,
+		UserID:  userID,
+		Banks:   banks,
+		Version: version,
 	}, nil
 }
 
 func (s *Storage) SearchByCategory(ctx context.Context, userID int64, monthStr, categoryName string) ([]domain.Bank, error) {
 	monthTime, err := time.Parse("2006-01", monthStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid month: %w", err)
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
 	}
 
 	rows, err := s.db.Query(ctx, `
@@ -279,9 +390,9 @@ func (s *Storage) SearchByCategory(ctx context.Context, userID int64, monthStr,
 		JOIN banks b ON b.id = bcc.bank_id
 		JOIN categories c ON c.id = bcc.category_id
 		JOIN cashback_months cm ON cm.id = bcc.cashback_month_id
-		WHERE cm.user_id = $1 AND cm.month = $2 AND c.name ILIKE $3
+		WHERE cm.user_id = $1 AND cm.month = $2 AND c.name ILIKE '%' || $3 || '%'
 		ORDER BY b.name
-	`, userID, monthTime, categoryName)
+	`, userID, monthTime, escapeLike(categoryName))
 	if err != nil {
 		return nil, fmt.Errorf("search banks by category: %w", err)
 	}
@@ -301,7 +412,7 @@ func (s *Storage) SearchByCategory(ctx context.Context, userID int64, monthStr,
 func (s *Storage) SearchByBank(ctx context.Context, userID int64, monthStr, bankName string) ([]domain.Category, error) {
 	monthTime, err := time.Parse("2006-01", monthStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid month: %w", err)
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
 	}
 
 	rows, err := s.db.Query(ctx, `
@@ -310,9 +421,9 @@ func (s *Storage) SearchByBank(ctx context.Context, userID int64, monthStr, bank
 		JOIN banks b ON b.id = bcc.bank_id
 		JOIN categories c ON c.id = bcc.category_id
 		JOIN cashback_months cm ON cm.id = bcc.cashback_month_id
-		WHERE cm.user_id = $1 AND cm.month = $2 AND b.name ILIKE $3
+		WHERE cm.user_id = $1 AND cm.month = $2 AND b.name ILIKE '%' || $3 || '%'
 		ORDER BY c.name
-	`, userID, monthTime, bankName)
+	`, userID, monthTime, escapeLike(bankName))
 	if err != nil {
 		return nil, fmt.Errorf("search categories by bank: %w", err)
 	}
@@ -329,30 +440,39 @@ func (s *Storage) SearchByBank(ctx context.Context, userID int64, monthStr, bank
 	return categories, rows.Err()
 }
 
-func (s *Storage) UpdateBankCategories(ctx context.Context, userID int64, monthStr, bankName string, newCategories []domain.CashbackCategory) error {
+func (s *Storage) UpdateBankCategories(ctx context.Context, userID int64, monthStr, bankName string, newCategories []domain.CashbackCategory) (*domain.MonthWrite, error) {
 	if len(newCategories) == 0 {
-		return fmt.Errorf("categories list cannot be empty")
+		return nil, storage.Invalid("storage.categories_empty")
 	}
 	for _, cc := range newCategories {
 		if strings.TrimSpace(cc.Category.Name) == "" {
-			return fmt.Errorf("category name cannot be empty")
+			return nil, storage.Invalid("storage.category_blank")
 		}
 		if cc.Percent < 0 || cc.Percent > 100 {
-			return fmt.Errorf("percent must be between 0 and 100 for category %q", cc.Category.Name)
+			return nil, storage.Invalid("storage.percent_range", cc.Category.Name)
 		}
 	}
 
 	monthTime, err := time.Parse("2006-01", monthStr)
 	if err != nil {
-		return fmt.Errorf("invalid month: %w", err)
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
 	}
 
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	_, _, err = lockMonth(ctx, tx, userID, monthTime)
+	if err != nil {
+		return nil, err
+	}
+	before, err := readMonth(ctx, tx, userID, monthStr)
+	if err != nil {
+		return nil, err
+	}
+
 	var monthID, bankID int
 	err = tx.QueryRow(ctx, `
 		SELECT cm.id, b.id
@@ -364,9 +484,9 @@ func (s *Storage) UpdateBankCategories(ctx context.Context, userID int64, monthS
 	`, userID, monthTime, bankName).Scan(&monthID, &bankID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return fmt.Errorf("bank %q not found in month %s", bankName, monthStr)
+			return nil, fmt.Errorf("%w: bank %q in month %s", storage.ErrNotFound, bankName, monthStr)
 		}
-		return fmt.Errorf("find bank in month: %w", err)
+		return nil, fmt.Errorf("find bank in month: %w", err)
 	}
 
 	_, err = tx.Exec(ctx, `
@@ -374,7 +494,7 @@ func (s *Storage) UpdateBankCategories(ctx context.Context, userID int64, monthS
 		WHERE cashback_month_id = $1 AND bank_id = $2
 	`, monthID, bankID)
 	if err != nil {
-		return fmt.Errorf("clear old categories: %w", err)
+		return nil, fmt.Errorf("clear old categories: %w", err)
 	}
 
 	for _, cc := range newCategories {
@@ -385,7 +505,7 @@ func (s *Storage) UpdateBankCategories(ctx context.Context, userID int64, monthS
 			RETURNING id
 		`, cc.Category.Name).Scan(&catID)
 		if err != nil {
-			return fmt.Errorf("create category %q: %w", cc.Category.Name, err)
+			return nil, fmt.Errorf("create category %q: %w", cc.Category.Name, err)
 		}
 
 		_, err = tx.Exec(ctx, `
@@ -395,111 +515,192 @@ func (s *Storage) UpdateBankCategories(ctx context.Context, userID int64, monthS
 			DO UPDATE SET percent = EXCLUDED.percent
 		`, monthID, bankID, catID, cc.Percent)
 		if err != nil {
-			return fmt.Errorf("link category: %w", err)
+			return nil, fmt.Errorf("link category: %w", err)
 		}
 	}
 
-	return tx.Commit(ctx)
+	version, err := bumpVersion(ctx, tx, monthID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordEvent(ctx, tx, domain.Event{Type: domain.EventBankUpdated, UserID: userID, Month: monthStr, Bank: bankName, Version: version}); err != nil {
+		return nil, err
+	}
+	after, err := readMonth(ctx, tx, userID, monthStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return &domain.MonthWrite{Before: before, After: after}, nil
 }
 
 func (s *Storage) DeleteBankFromMonth(ctx context.Context, userID int64, monthStr, bankName string) error {
 	monthTime, err := time.Parse("2006-01", monthStr)
 	if err != nil {
-		return fmt.Errorf("invalid month: %w", err)
+		return storage.Invalid("storage.month_invalid", monthStr)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	monthID, _, err := lockMonth(ctx, tx, userID, monthTime)
+	if err != nil {
+		return err
 	}
 
 	bankName = sanitizeString(bankName)
-	_, err = s.db.Exec(ctx, `
+	result, err := tx.Exec(ctx, `
 		DELETE FROM bank_cashback_categories
-		USING banks b, cashback_months cm
+		USING banks b
 		WHERE bank_cashback_categories.bank_id = b.id
-		AND bank_cashback_categories.cashback_month_id = cm.id
-		AND cm.user_id = $1
-		AND cm.month = $2
-		AND b.name = $3
-	`, userID, monthTime, bankName)
+		AND bank_cashback_categories.cashback_month_id = $1
+		AND b.name = $2
+	`, monthID, bankName)
 
 	if err != nil {
 		return fmt.Errorf("delete bank from month: %w", err)
 	}
-	return nil
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: bank %q in month %s", storage.ErrNotFound, bankName, monthStr)
+	}
+	version, err := bumpVersion(ctx, tx, monthID)
+	if err != nil {
+		return err
+	}
+	if err := recordEvent(ctx, tx, domain.Event{Type: domain.EventBankDeleted, UserID: userID, Month: monthStr, Bank: bankName, Version: version}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (s *Storage) DeleteCategoryFromBank(ctx context.Context, userID int64, monthStr, bankName, categoryName string) error {
 	monthTime, err := time.Parse("2006-01", monthStr)
-	log.Printf("🗑️ SQL: DELETE category='%s' from bank='%s'", categoryName, bankName)
 	if err != nil {
-		return fmt.Errorf("invalid month: %w", err)
+		return storage.Invalid("storage.month_invalid", monthStr)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	monthID, _, err := lockMonth(ctx, tx, userID, monthTime)
+	if err != nil {
+		return err
 	}
 
 	bankName = sanitizeString(bankName)
-	result, err := s.db.Exec(ctx, `
+	result, err := tx.Exec(ctx, `
 		DELETE FROM bank_cashback_categories
-		USING banks b, categories c, cashback_months cm
+		USING banks b, categories c
 		WHERE bank_cashback_categories.bank_id = b.id
 		AND bank_cashback_categories.category_id = c.id
-		AND bank_cashback_categories.cashback_month_id = cm.id
-		AND cm.user_id = $1
-		AND cm.month = $2
-		AND b.name = $3
-		AND c.name = $4
-	`, userID, monthTime, bankName, categoryName)
+		AND bank_cashback_categories.cashback_month_id = $1
+		AND b.name = $2
+		AND c.name = $3
+	`, monthID, bankName, categoryName)
 
 	if err != nil {
 		return fmt.Errorf("delete category from bank: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("category %q not found for bank %q in %s", categoryName, bankName, monthStr)
+		return fmt.Errorf("%w: category %q for bank %q in %s", storage.ErrNotFound, categoryName, bankName, monthStr)
 	}
 
-	return nil
+	version, err := bumpVersion(ctx, tx, monthID)
+	if err != nil {
+		return err
+	}
+	if err := recordEvent(ctx, tx, domain.Event{Type: domain.EventCategoryDeleted, UserID: userID, Month: monthStr, Bank: bankName, Category: categoryName, Version: version}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Storage) DeleteMonth(ctx context.Context, userID int64, monthStr string) error {
+	monthTime, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		return storage.Invalid("storage.month_invalid", monthStr)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	monthID, _, err := lockMonth(ctx, tx, userID, monthTime)
+	if err != nil {
+		return err
+	}
+	if monthID == 0 {
+		return fmt.Errorf("%w: month %s", storage.ErrNotFound, monthStr)
+	}
+
+	// строки bank_cashback_categories удаляются каскадом
+	if _, err := tx.Exec(ctx, "DELETE FROM cashback_months WHERE id = $1", monthID); err != nil {
+		return fmt.Errorf("delete month: %w", err)
+	}
+	if err := recordEvent(ctx, tx, domain.Event{Type: domain.EventMonthDeleted, UserID: userID, Month: monthStr}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-func (s *Storage) PatchMonth(ctx context.Context, userID int64, monthStr string, bankCategories []domain.BankWithCategories) error {
+func (s *Storage) PatchMonth(ctx context.Context, userID int64, monthStr string, bankCategories []domain.BankWithCategories) (*domain.MonthWrite, error) {
 	for _, bc := range bankCategories {
 		if strings.TrimSpace(bc.Bank.Name) == "" {
-			return fmt.Errorf("bank name cannot be empty")
+			return nil, storage.Invalid("storage.bank_blank")
 		}
 		if len(bc.Categories) == 0 {
-			return fmt.Errorf("bank %q must have at least one category", bc.Bank.Name)
+			return nil, storage.Invalid("storage.bank_no_categories", bc.Bank.Name)
 		}
 		for _, cc := range bc.Categories {
 			if strings.TrimSpace(cc.Category.Name) == "" {
-				return fmt.Errorf("category name cannot be empty for bank %q", bc.Bank.Name)
+				return nil, storage.Invalid("storage.category_blank_in_bank", bc.Bank.Name)
 			}
 			if cc.Percent < 0 || cc.Percent > 100 {
-				return fmt.Errorf("percent must be between 0 and 100 for category %q", cc.Category.Name)
+				return nil, storage.Invalid("storage.percent_range", cc.Category.Name)
 			}
 		}
 	}
 
 	monthTime, err := time.Parse("2006-01", monthStr)
 	if err != nil {
-		return fmt.Errorf("invalid month format: %w", err)
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
 	}
 
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	var monthID int
-	err = tx.QueryRow(ctx, `
-		SELECT id FROM cashback_months WHERE user_id = $1 AND month = $2
-	`, userID, monthTime).Scan(&monthID)
+	monthID, _, err := lockMonth(ctx, tx, userID, monthTime)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			err = tx.QueryRow(ctx, `
-				INSERT INTO cashback_months (user_id, month) VALUES ($1, $2) RETURNING id
-			`, userID, monthTime).Scan(&monthID)
-			if err != nil {
-				return fmt.Errorf("create month: %w", err)
-			}
-		} else {
-			return fmt.Errorf("check month: %w", err)
+		return nil, err
+	}
+	before, err := readMonth(ctx, tx, userID, monthStr)
+	if err != nil {
+		return nil, err
+	}
+	var version int
+	if monthID == 0 {
+		err = tx.QueryRow(ctx, `
+			INSERT INTO cashback_months (user_id, month) VALUES ($1, $2) RETURNING id, version
+		`, userID, monthTime).Scan(&monthID, &version)
+		if err != nil {
+			return nil, fmt.Errorf("create month: %w", conflict(err))
 		}
+	} else if version, err = bumpVersion(ctx, tx, monthID); err != nil {
+		return nil, err
 	}
 
 	createBankInTx := func(name string) (int, error) {
@@ -525,13 +726,13 @@ func (s *Storage) PatchMonth(ctx context.Context, userID int64, monthStr string,
 	for _, bc := range bankCategories {
 		bankID, err := createBankInTx(bc.Bank.Name)
 		if err != nil {
-			return fmt.Errorf("create bank %q: %w", bc.Bank.Name, err)
+			return nil, fmt.Errorf("create bank %q: %w", bc.Bank.Name, err)
 		}
 
 		for _, cc := range bc.Categories {
 			categoryID, err := createCategoryInTx(cc.Category.Name)
 			if err != nil {
-				return fmt.Errorf("create category %q: %w", cc.Category.Name, err)
+				return nil, fmt.Errorf("create category %q: %w", cc.Category.Name, err)
 			}
 
 			_, err = tx.Exec(ctx, `
@@ -541,10 +742,1006 @@ func (s *Storage) PatchMonth(ctx context.Context, userID int64, monthStr string,
 				DO UPDATE SET percent = EXCLUDED.percent
 			`, monthID, bankID, categoryID, cc.Percent)
 			if err != nil {
-				return fmt.Errorf("upsert bank-category: %w", err)
+				return nil, fmt.Errorf("upsert bank-category: %w", err)
 			}
 		}
 	}
 
-	return tx.Commit(ctx)
-}
\ No newline at end of file
+	if err := recordEvent(ctx, tx, domain.Event{Type: domain.EventMonthPatched, UserID: userID, Month: monthStr, Version: version}); err != nil {
+		return nil, err
+	}
+	after, err := readMonth(ctx, tx, userID, monthStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return &domain.MonthWrite{Before: before, After: after}, nil
+}
+func (s *Storage) GetRange(ctx context.Context, userID int64, fromStr, toStr string) ([]domain.CashbackRow, error) {
+	fromTime, err := time.Parse("2006-01", fromStr)
+	if err != nil {
+		return nil, storage.Invalid("storage.month_invalid", fromStr)
+	}
+	toTime, err := time.Parse("2006-01", toStr)
+	if err != nil {
+		return nil, storage.Invalid("storage.month_invalid", toStr)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			to_char(cm.month, 'YYYY-MM'),
+			b.name,
+			c.name,
+			bcc.percent
+		FROM bank_cashback_categories bcc
+		JOIN cashback_months cm ON cm.id = bcc.cashback_month_id
+		JOIN banks b ON b.id = bcc.bank_id
+		JOIN categories c ON c.id = bcc.category_id
+		WHERE cm.user_id = $1 AND cm.month BETWEEN $2 AND $3
+		ORDER BY cm.month, b.name, c.name
+	`, userID, fromTime, toTime)
+	if err != nil {
+		return nil, fmt.Errorf("query range: %w", err)
+	}
+	defer rows.Close()
+
+	var result []domain.CashbackRow
+	for rows.Next() {
+		var row domain.CashbackRow
+		var percent float64
+		if err := rows.Scan(&row.Month, &row.Bank, &row.Category, &percent); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		row.Percent = float32(percent)
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func (s *Storage) GetMonths(ctx context.Context, userID int64, months []string) ([]domain.CashbackMonth, error) {
+	monthTimes := make([]time.Time, len(months))
+	for i, m := range months {
+		t, err := time.Parse("2006-01", m)
+		if err != nil {
+			return nil, storage.Invalid("storage.month_invalid", m)
+		}
+		monthTimes[i] = t
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			to_char(cm.month, 'YYYY-MM'),
+			b.id, b.name,
+			c.id, c.name,
+			bcc.percent
+		FROM cashback_months cm
+		JOIN bank_cashback_categories bcc ON bcc.cashback_month_id = cm.id
+		JOIN banks b ON b.id = bcc.bank_id
+		JOIN categories c ON c.id = bcc.category_id
+		WHERE cm.user_id = $1 AND cm.month = ANY($2)
+		ORDER BY cm.month, b.name, c.name
+	`, userID, monthTimes)
+	if err != nil {
+		return nil, fmt.Errorf("query months: %w", err)
+	}
+	defer rows.Close()
+
+	var result []domain.CashbackMonth
+	for rows.Next() {
+		var month, bankName, catName string
+		var bankID, catID int
+		var percent float64
+		if err := rows.Scan(&month, &bankID, &bankName, &catID, &catName, &percent); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		// строки отсортированы, поэтому месяц и банк меняются только вперёд
+		if len(result) == 0 || result[len(result)-1].Month != month {
+			result = append(result, domain.CashbackMonth{Month: month, UserID: userID})
+		}
+		cm := &result[len(result)-1]
+		if len(cm.Banks) == 0 || cm.Banks[len(cm.Banks)-1].Bank.ID != bankID {
+			cm.Banks = append(cm.Banks, domain.BankWithCategories{Bank: domain.Bank{ID: bankID, Name: bankName}})
+		}
+		bwc := &cm.Banks[len(cm.Banks)-1]
+		bwc.Categories = append(bwc.Categories, domain.CashbackCategory{
+			Category: domain.Category{ID: catID, Name: catName},
+			Percent:  float32(percent),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
+
+func (s *Storage) GetMonthByCategory(ctx context.Context, userID int64, monthStr, categoryName string) ([]domain.CategoryBanks, error) {
+	monthTime, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT c.name, b.name, bcc.percent
+		FROM cashback_months cm
+		JOIN bank_cashback_categories bcc ON bcc.cashback_month_id = cm.id
+		JOIN banks b ON b.id = bcc.bank_id
+		JOIN categories c ON c.id = bcc.category_id
+		WHERE cm.user_id = $1 AND cm.month = $2 AND ($3 = '' OR lower(c.name) = lower($3))
+		ORDER BY c.name, bcc.percent DESC, b.name
+	`, userID, monthTime, sanitizeString(categoryName))
+	if err != nil {
+		return nil, fmt.Errorf("query month by category: %w", err)
+	}
+	defer rows.Close()
+
+	var result []domain.CategoryBanks
+	for rows.Next() {
+		var catName, bankName string
+		var percent float64
+		if err := rows.Scan(&catName, &bankName, &percent); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		if len(result) == 0 || result[len(result)-1].Category != catName {
+			result = append(result, domain.CategoryBanks{Category: catName})
+		}
+		cb := &result[len(result)-1]
+		cb.Banks = append(cb.Banks, domain.BankPercent{Bank: bankName, Percent: float32(percent)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
+
+func (s *Storage) SearchHistory(ctx context.Context, userID int64, filter domain.HistoryFilter) ([]domain.CashbackRow, error) {
+	fromTime, err := time.Parse("2006-01", filter.From)
+	if err != nil {
+		return nil, storage.Invalid("storage.month_invalid", filter.From)
+	}
+	toTime, err := time.Parse("2006-01", filter.To)
+	if err != nil {
+		return nil, storage.Invalid("storage.month_invalid", filter.To)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			to_char(cm.month, 'YYYY-MM'),
+			b.name,
+			c.name,
+			bcc.percent
+		FROM cashback_months cm
+		JOIN bank_cashback_categories bcc ON bcc.cashback_month_id = cm.id
+		JOIN banks b ON b.id = bcc.bank_id
+		JOIN categories c ON c.id = bcc.category_id
+		WHERE cm.user_id = $1
+			AND cm.month BETWEEN $2 AND $3
+			AND ($4 = '' OR lower(c.name) = lower($4))
+			AND ($5 = '' OR lower(b.name) = lower($5))
+			AND bcc.percent >= $6
+		ORDER BY cm.month DESC, bcc.percent DESC, b.name, c.name
+	`, userID, fromTime, toTime, sanitizeString(filter.Category), sanitizeString(filter.Bank), filter.MinPercent)
+	if err != nil {
+		return nil, fmt.Errorf("search history: %w", err)
+	}
+	defer rows.Close()
+
+	var result []domain.CashbackRow
+	for rows.Next() {
+		var row domain.CashbackRow
+		var percent float64
+		if err := rows.Scan(&row.Month, &row.Bank, &row.Category, &percent); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		row.Percent = float32(percent)
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
+
+// UsersWithMonth — пользователи, у которых есть данные за месяц
+func (s *Storage) UsersWithMonth(ctx context.Context, monthStr string) ([]int64, error) {
+	monthTime, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
+	}
+	rows, err := s.db.Query(ctx, "SELECT DISTINCT user_id FROM cashback_months WHERE month = $1", monthTime)
+	if err != nil {
+		return nil, fmt.Errorf("query month users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return users, nil
+}
+
+// === SettingsStorage ===
+
+// GetLanguage возвращает сохранённый язык пользователя или "", если он не выбран
+func (s *Storage) GetLanguage(ctx context.Context, userID int64) (string, error) {
+	var lang *string
+	err := s.db.QueryRow(ctx, "SELECT language FROM user_settings WHERE user_id = $1", userID).Scan(&lang)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("get language: %w", err)
+	}
+	if lang == nil {
+		return "", nil
+	}
+	return *lang, nil
+}
+
+func (s *Storage) SetLanguage(ctx context.Context, userID int64, lang string) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO user_settings (user_id, language) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET language = EXCLUDED.language
+	`, userID, lang)
+	if err != nil {
+		return fmt.Errorf("set language: %w", err)
+	}
+	return nil
+}
+
+// === UpdateStorage ===
+
+// MarkUpdateProcessed запоминает update_id; false — обновление уже приходило
+func (s *Storage) MarkUpdateProcessed(ctx context.Context, updateID int64) (bool, error) {
+	result, err := s.db.Exec(ctx, `
+		INSERT INTO telegram_updates (update_id) VALUES ($1)
+		ON CONFLICT (update_id) DO NOTHING
+	`, updateID)
+	if err != nil {
+		return false, fmt.Errorf("mark update processed: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (s *Storage) PurgeProcessedUpdates(ctx context.Context, olderThan time.Time) (int64, error) {
+	result, err := s.db.Exec(ctx, "DELETE FROM telegram_updates WHERE received_at < $1", olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("purge processed updates: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// === IdempotencyStorage ===
+
+func (s *Storage) ReserveIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord, expiredBefore time.Time) (*domain.IdempotencyRecord, error) {
+	// устаревшая запись заменяется, свежая остаётся как есть
+	result, err := s.db.Exec(ctx, `
+		INSERT INTO idempotency_keys (user_id, key, route, request_hash)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, key, route) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status = 0, content_type = '', body = NULL, created_at = NOW()
+		WHERE idempotency_keys.created_at < $5
+	`, rec.UserID, rec.Key, rec.Route, rec.RequestHash, expiredBefore)
+	if err != nil {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+	if result.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	existing := domain.IdempotencyRecord{UserID: rec.UserID, Key: rec.Key, Route: rec.Route}
+	err = s.db.QueryRow(ctx, `
+		SELECT request_hash, status, content_type, COALESCE(body, ''::bytea), created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND route = $3
+	`, rec.UserID, rec.Key, rec.Route).Scan(&existing.RequestHash, &existing.Status, &existing.ContentType, &existing.Body, &existing.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+	return &existing, nil
+}
+
+func (s *Storage) CompleteIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status = $4, content_type = $5, body = $6
+		WHERE user_id = $1 AND key = $2 AND route = $3
+	`, rec.UserID, rec.Key, rec.Route, rec.Status, rec.ContentType, rec.Body)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, userID int64, key, route string) error {
+	_, err := s.db.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND route = $3 AND status = 0
+	`, userID, key, route)
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) PurgeIdempotencyKeys(ctx context.Context, olderThan time.Time) (int64, error) {
+	result, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE created_at < $1", olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// === WebhookStorage ===
+
+const webhookColumns = "id, user_id, url, secret, events, enabled, created_at"
+
+func scanWebhook(row pgx.Row) (*domain.Webhook, error) {
+	var hook domain.Webhook
+	var events []string
+	if err := row.Scan(&hook.ID, &hook.UserID, &hook.URL, &hook.Secret, &events, &hook.Enabled, &hook.CreatedAt); err != nil {
+		return nil, err
+	}
+	hook.Events = make([]domain.EventType, len(events))
+	for i, e := range events {
+		hook.Events[i] = domain.EventType(e)
+	}
+	return &hook, nil
+}
+
+func eventNames(types []domain.EventType) []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return names
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, hook domain.Webhook, limit int) (*domain.Webhook, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// строк пользователя может ещё не быть, поэтому FOR UPDATE по ним не поможет:
+	// параллельные создания одного пользователя ждут друг друга на advisory-блокировке
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended('webhooks:' || $1::text, 0))", hook.UserID); err != nil {
+		return nil, fmt.Errorf("lock user webhooks: %w", err)
+	}
+	var count int
+	if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM webhooks WHERE user_id = $1", hook.UserID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("count webhooks: %w", err)
+	}
+	if count >= limit {
+		return nil, fmt.Errorf("%w: %d webhooks", storage.ErrLimitExceeded, count)
+	}
+
+	created, err := scanWebhook(tx.QueryRow(ctx, `
+		INSERT INTO webhooks (user_id, url, secret, events, enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+webhookColumns,
+		hook.UserID, hook.URL, hook.Secret, eventNames(hook.Events), hook.Enabled))
+	if err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return created, nil
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context, userID int64) ([]domain.Webhook, error) {
+	rows, err := s.db.Query(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []domain.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook: %w", err)
+		}
+		hooks = append(hooks, *hook)
+	}
+	return hooks, rows.Err()
+}
+
+func (s *Storage) GetWebhook(ctx context.Context, userID, id int64) (*domain.Webhook, error) {
+	hook, err := scanWebhook(s.db.QueryRow(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 AND id = $2", userID, id))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: webhook %d", storage.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+	return hook, nil
+}
+
+func (s *Storage) UpdateWebhook(ctx context.Context, hook domain.Webhook) error {
+	result, err := s.db.Exec(ctx, `
+		UPDATE webhooks SET url = $3, events = $4, enabled = $5
+		WHERE user_id = $1 AND id = $2
+	`, hook.UserID, hook.ID, hook.URL, eventNames(hook.Events), hook.Enabled)
+	if err != nil {
+		return fmt.Errorf("update webhook: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: webhook %d", storage.ErrNotFound, hook.ID)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, userID, id int64) error {
+	// журнал доставок удаляется каскадом
+	result, err := s.db.Exec(ctx, "DELETE FROM webhooks WHERE user_id = $1 AND id = $2", userID, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: webhook %d", storage.ErrNotFound, id)
+	}
+	return nil
+}
+
+func (s *Storage) EnqueueDeliveries(ctx context.Context, e domain.Event, payload []byte) (int64, error) {
+	result, err := s.db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT id, $2, $3, $4 FROM webhooks
+		WHERE user_id = $1 AND enabled AND (cardinality(events) = 0 OR $3 = ANY(events))
+		ON CONFLICT (webhook_id, event_id) DO NOTHING
+	`, e.UserID, e.ID, string(e.Type), payload)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func (s *Storage) ClaimDueDeliveries(ctx context.Context, now, lockedUntil time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	// доставки выключенного webhook ждут, пока его включат
+	rows, err := s.db.Query(ctx, `
+		WITH due AS (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id AND w.enabled
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $1
+			AND (d.locked_until IS NULL OR d.locked_until < $1)
+			ORDER BY d.next_attempt_at
+			LIMIT $3
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET locked_until = $2
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.attempts, d.payload, d.created_at, w.url, w.secret
+	`, now, lockedUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		d := domain.WebhookDelivery{Status: domain.DeliveryPending}
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Attempts, &d.Payload, &d.CreatedAt, &d.URL, &d.Secret); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (s *Storage) RecordDeliveryAttempt(ctx context.Context, d domain.WebhookDelivery) error {
+	nextAttemptAt := time.Now()
+	if d.NextAttemptAt != nil {
+		nextAttemptAt = *d.NextAttemptAt
+	}
+	_, err := s.db.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, last_status = $4, last_error = $5,
+			next_attempt_at = $6, delivered_at = $7, locked_until = NULL
+		WHERE id = $1
+	`, d.ID, string(d.Status), d.Attempts, d.LastStatus, d.LastError, nextAttemptAt, d.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListDeliveries(ctx context.Context, userID, webhookID int64, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT d.id, d.webhook_id, d.event_id, d.event_type, d.status, d.attempts,
+			d.last_status, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE w.user_id = $1 AND d.webhook_id = $2
+		ORDER BY d.id DESC
+		LIMIT $3
+	`, userID, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		var nextAttemptAt time.Time
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&d.LastStatus, &d.LastError, &nextAttemptAt, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		// время следующей попытки имеет смысл только для ожидающих доставок
+		if d.Status == domain.DeliveryPending {
+			d.NextAttemptAt = &nextAttemptAt
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *Storage) PurgeDeliveries(ctx context.Context, olderThan time.Time) (int64, error) {
+	result, err := s.db.Exec(ctx, "DELETE FROM webhook_deliveries WHERE created_at < $1 AND status <> 'pending'", olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("purge webhook deliveries: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// === OutboxStorage ===
+
+func (s *Storage) ClaimOutboxEvents(ctx context.Context, now, lockedUntil time.Time, limit int) ([]domain.Event, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH due AS (
+			SELECT id FROM outbox_events
+			WHERE published_at IS NULL AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE outbox_events o SET locked_until = $2
+			FROM due WHERE o.id = due.id
+			RETURNING o.id, o.user_id, o.type, o.month, o.bank, o.category, o.version, o.created_at
+		)
+		SELECT * FROM claimed ORDER BY id
+	`, now, lockedUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.Event
+	for rows.Next() {
+		var e domain.Event
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Type, &e.Month, &e.Bank, &e.Category, &e.Version, &e.At); err != nil {
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return events, nil
+}
+
+func (s *Storage) MarkOutboxPublished(ctx context.Context, ids []int64) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE outbox_events SET published_at = NOW(), locked_until = NULL
+		WHERE id = ANY($1)
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("mark outbox published: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) PurgeOutboxEvents(ctx context.Context, olderThan time.Time) (int64, error) {
+	result, err := s.db.Exec(ctx, "DELETE FROM outbox_events WHERE published_at < $1", olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("purge outbox events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// === DeadLetterStorage ===
+
+func (s *Storage) SaveDeadLetter(ctx context.Context, letter domain.DeadLetter) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO telegram_dead_letters (chat_id, kind, payload, error, attempts)
+		VALUES ($1, $2, $3, $4, $5)
+	`, letter.ChatID, letter.Kind, letter.Payload, letter.Error, letter.Attempts)
+	if err != nil {
+		return fmt.Errorf("save dead letter: %w", err)
+	}
+	return nil
+}
+
+// === ReminderStorage ===
+
+// GetReminder возвращает настройки напоминания или nil, если пользователь их не задавал
+func (s *Storage) GetReminder(ctx context.Context, userID int64) (*domain.ReminderSettings, error) {
+	settings := domain.ReminderSettings{UserID: userID}
+	err := s.db.QueryRow(ctx, `
+		SELECT day, remind_time, timezone, enabled
+		FROM reminder_settings WHERE user_id = $1
+	`, userID).Scan(&settings.Day, &settings.Time, &settings.Timezone, &settings.Enabled)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get reminder: %w", err)
+	}
+	return &settings, nil
+}
+
+func (s *Storage) SaveReminder(ctx context.Context, settings domain.ReminderSettings) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO reminder_settings (user_id, day, remind_time, timezone, enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			day = EXCLUDED.day,
+			remind_time = EXCLUDED.remind_time,
+			timezone = EXCLUDED.timezone,
+			enabled = EXCLUDED.enabled,
+			updated_at = now()
+	`, settings.UserID, settings.Day, settings.Time, settings.Timezone, settings.Enabled)
+	if err != nil {
+		return fmt.Errorf("save reminder: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteReminder(ctx context.Context, userID int64) error {
+	if _, err := s.db.Exec(ctx, "DELETE FROM reminder_settings WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("delete reminder: %w", err)
+	}
+	return nil
+}
+
+// === JobStorage ===
+
+// ScheduleJob создаёт задачу или переносит существующую того же вида, сбрасывая ошибки
+func (s *Storage) ScheduleJob(ctx context.Context, kind string, userID int64, runAt time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO scheduled_jobs (kind, user_id, run_at) VALUES ($1, $2, $3)
+		ON CONFLICT (kind, user_id) DO UPDATE SET
+			run_at = EXCLUDED.run_at,
+			locked_until = NULL,
+			attempts = 0,
+			last_error = NULL
+	`, kind, userID, runAt)
+	if err != nil {
+		return fmt.Errorf("schedule job: %w", err)
+	}
+	return nil
+}
+
+// EnsureJob создаёт задачу, только если её ещё нет — для системных задач, заводимых при старте
+func (s *Storage) EnsureJob(ctx context.Context, kind string, userID int64, runAt time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO scheduled_jobs (kind, user_id, run_at) VALUES ($1, $2, $3)
+		ON CONFLICT (kind, user_id) DO NOTHING
+	`, kind, userID, runAt)
+	if err != nil {
+		return fmt.Errorf("ensure job: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) CancelJob(ctx context.Context, kind string, userID int64) error {
+	if _, err := s.db.Exec(ctx, "DELETE FROM scheduled_jobs WHERE kind = $1 AND user_id = $2", kind, userID); err != nil {
+		return fmt.Errorf("cancel job: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueJobs забирает созревшие задачи; SKIP LOCKED не даёт двум процессам взять одну и ту же
+func (s *Storage) ClaimDueJobs(ctx context.Context, now, lockedUntil time.Time, limit int) ([]domain.Job, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH due AS (
+			SELECT id FROM scheduled_jobs
+			WHERE run_at <= $1 AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY run_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE scheduled_jobs j SET locked_until = $2
+		FROM due WHERE j.id = due.id
+		RETURNING j.id, j.kind, j.user_id, j.run_at, j.attempts
+	`, now, lockedUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []domain.Job
+	for rows.Next() {
+		var job domain.Job
+		if err := rows.Scan(&job.ID, &job.Kind, &job.UserID, &job.RunAt, &job.Attempts); err != nil {
+			return nil, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return jobs, nil
+}
+
+func (s *Storage) RescheduleJob(ctx context.Context, id int64, runAt time.Time, attempts int, lastError string) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE scheduled_jobs
+		SET run_at = $2, attempts = $3, last_error = NULLIF($4, ''), locked_until = NULL
+		WHERE id = $1
+	`, id, runAt, attempts, lastError)
+	if err != nil {
+		return fmt.Errorf("reschedule job: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteJob(ctx context.Context, id int64) error {
+	if _, err := s.db.Exec(ctx, "DELETE FROM scheduled_jobs WHERE id = $1", id); err != nil {
+		return fmt.Errorf("delete job: %w", err)
+	}
+	return nil
+}
+
+// === DeadlineStorage ===
+
+func (s *Storage) SetDeadlineRule(ctx context.Context, userID int64, rule domain.DeadlineRule) error {
+	bankID, err := s.CreateIfNotExists(ctx, sanitizeString(rule.Bank))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO bank_deadline_rules (user_id, bank_id, day, month_offset) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, bank_id) DO UPDATE SET day = EXCLUDED.day, month_offset = EXCLUDED.month_offset
+	`, userID, bankID, rule.Day, rule.MonthOffset)
+	if err != nil {
+		return fmt.Errorf("set deadline rule: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteDeadlineRule(ctx context.Context, userID int64, bankName string) error {
+	_, err := s.db.Exec(ctx, `
+		DELETE FROM bank_deadline_rules
+		WHERE user_id = $1 AND bank_id = (SELECT id FROM banks WHERE name = $2)
+	`, userID, sanitizeString(bankName))
+	if err != nil {
+		return fmt.Errorf("delete deadline rule: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListDeadlineRules(ctx context.Context, userID int64) ([]domain.DeadlineRule, error) {
+	return s.queryDeadlineRules(ctx, `
+		SELECT b.name, r.day, r.month_offset
+		FROM bank_deadline_rules r
+		JOIN banks b ON b.id = r.bank_id
+		WHERE r.user_id = $1
+		ORDER BY b.name
+	`, userID)
+}
+
+func (s *Storage) queryDeadlineRules(ctx context.Context, query string, args ...any) ([]domain.DeadlineRule, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query deadline rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []domain.DeadlineRule
+	for rows.Next() {
+		var rule domain.DeadlineRule
+		if err := rows.Scan(&rule.Bank, &rule.Day, &rule.MonthOffset); err != nil {
+			return nil, fmt.Errorf("scan deadline rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return rules, nil
+}
+
+func (s *Storage) UsersWithDeadlineRules(ctx context.Context) ([]int64, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT user_id FROM bank_deadline_rules
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query deadline users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return users, nil
+}
+
+// MarkDeadlineAlerted запоминает отправленное предупреждение; false — оно уже было
+func (s *Storage) MarkDeadlineAlerted(ctx context.Context, userID int64, bankName, monthStr string) (bool, error) {
+	monthTime, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		return false, storage.Invalid("storage.month_invalid", monthStr)
+	}
+	result, err := s.db.Exec(ctx, `
+		INSERT INTO deadline_alerts (user_id, bank_id, month)
+		SELECT $1, id, $3 FROM banks WHERE name = $2
+		ON CONFLICT DO NOTHING
+	`, userID, bankName, monthTime)
+	if err != nil {
+		return false, fmt.Errorf("mark deadline alerted: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// === PurchaseStorage ===
+
+func (s *Storage) AddPurchase(ctx context.Context, userID int64, purchase domain.Purchase) error {
+	date, err := time.Parse("2006-01-02", purchase.Date)
+	if err != nil {
+		return storage.Invalid("storage.date_invalid", purchase.Date)
+	}
+	bankID, err := s.CreateIfNotExists(ctx, sanitizeString(purchase.Bank))
+	if err != nil {
+		return err
+	}
+	categoryID, err := s.CreateCategoryIfNotExists(ctx, sanitizeString(purchase.Category))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO purchases (user_id, purchased_on, bank_id, category_id, amount)
+		VALUES ($1, $2, $3, $4, $5)
+	`, userID, date, bankID, categoryID, purchase.Amount)
+	if err != nil {
+		return fmt.Errorf("add purchase: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) MonthPurchases(ctx context.Context, userID int64, monthStr string) ([]domain.PurchaseTotal, error) {
+	monthTime, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		return nil, storage.Invalid("storage.month_invalid", monthStr)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT b.name, c.name, SUM(p.amount)
+		FROM purchases p
+		JOIN banks b ON b.id = p.bank_id
+		JOIN categories c ON c.id = p.category_id
+		WHERE p.user_id = $1 AND p.purchased_on >= $2 AND p.purchased_on < $3
+		GROUP BY b.name, c.name
+		ORDER BY b.name, c.name
+	`, userID, monthTime, monthTime.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("query purchases: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []domain.PurchaseTotal
+	for rows.Next() {
+		var total domain.PurchaseTotal
+		if err := rows.Scan(&total.Bank, &total.Category, &total.Amount); err != nil {
+			return nil, fmt.Errorf("scan purchase: %w", err)
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return totals, nil
+}
+
+// === NameSearchStorage ===
+
+// userNamesQuery — названия банков или категорий, которые встречаются в данных пользователя
+var userNamesQuery = map[domain.NameKind]string{
+	domain.NameBank: `
+		SELECT DISTINCT b.name AS name
+		FROM banks b
+		JOIN bank_cashback_categories bcc ON bcc.bank_id = b.id
+		JOIN cashback_months cm ON cm.id = bcc.cashback_month_id
+		WHERE cm.user_id = $1`,
+	domain.NameCategory: `
+		SELECT DISTINCT c.name AS name
+		FROM categories c
+		JOIN bank_cashback_categories bcc ON bcc.category_id = c.id
+		JOIN cashback_months cm ON cm.id = bcc.cashback_month_id
+		WHERE cm.user_id = $1`,
+}
+
+// SuggestNames ищет похожие названия: через pg_trgm, если он есть, иначе — ранжированием в Go
+func (s *Storage) SuggestNames(ctx context.Context, userID int64, kind domain.NameKind, query string, limit int) ([]string, error) {
+	namesQuery, ok := userNamesQuery[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown name kind %q", kind)
+	}
+	normalized := fuzzy.Normalize(sanitizeString(query))
+	if normalized == "" {
+		return nil, nil
+	}
+
+	if s.hasTrigram(ctx) {
+		return s.queryNames(ctx, `
+			WITH names AS (`+namesQuery+`),
+			scored AS (
+				SELECT name, replace(lower(name), 'ё', 'е') AS norm FROM names
+			)
+			SELECT name FROM scored
+			WHERE norm LIKE '%' || $2 || '%' OR similarity(norm, $3) >= 0.3
+			ORDER BY norm = $3 DESC, norm LIKE $2 || '%' DESC, similarity(norm, $3) DESC, name
+			LIMIT $4
+		`, userID, escapeLike(normalized), normalized, limit)
+	}
+
+	names, err := s.queryNames(ctx, namesQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	return fuzzy.Rank(normalized, names, limit), nil
+}
+
+func (s *Storage) hasTrigram(ctx context.Context) bool {
+	s.trgmOnce.Do(func() {
+		err := s.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&s.trgm)
+		if err != nil {
+			slog.Warn("Не удалось проверить pg_trgm, подсказки считаются в Go", "error", err)
+			s.trgm = false
+		}
+	})
+	return s.trgm
+}
+
+func (s *Storage) queryNames(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query names: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan name: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return names, nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы пользовательский ввод искался буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
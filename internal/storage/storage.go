@@ -4,6 +4,7 @@ package storage
 import (
 	"cashback-tracker/internal/domain"
 	"context"
+	"time"
 )
 
 type BankStorage interface {
@@ -17,12 +18,128 @@ type CategoryStorage interface {
 }
 
 type CashbackStorage interface {
-	SaveMonth(ctx context.Context, userID int64, monthTime string, bankCategories []domain.BankWithCategories) error
+	// SaveMonth, UpdateBankCategories и PatchMonth возвращают месяц до и после записи,
+	// прочитанные в её транзакции: по ним отвечают, не перечитывая месяц
+	SaveMonth(ctx context.Context, userID int64, monthTime string, bankCategories []domain.BankWithCategories) (*domain.MonthWrite, error)
 	GetMonth(ctx context.Context, userID int64, monthTime string) (*domain.CashbackMonth, error)
 	SearchByCategory(ctx context.Context, userID int64, monthTime string, categoryName string) ([]domain.Bank, error)
 	SearchByBank(ctx context.Context, userID int64, monthTime string, bankName string) ([]domain.Category, error)
-	UpdateBankCategories(ctx context.Context, userID int64, monthTime string, bankName string, categories []domain.CashbackCategory) error
-	PatchMonth(ctx context.Context, userID int64, monthTime string, bankCategories []domain.BankWithCategories) error
+	UpdateBankCategories(ctx context.Context, userID int64, monthTime string, bankName string, categories []domain.CashbackCategory) (*domain.MonthWrite, error)
+	PatchMonth(ctx context.Context, userID int64, monthTime string, bankCategories []domain.BankWithCategories) (*domain.MonthWrite, error)
 	DeleteBankFromMonth(ctx context.Context, userID int64, monthTime string, bankName string) error
 	DeleteCategoryFromBank(ctx context.Context, userID int64, monthTime string, bankName string, categoryName string) error
+	// DeleteMonth удаляет месяц вместе со всеми банками и категориями
+	DeleteMonth(ctx context.Context, userID int64, monthTime string) error
+	GetRange(ctx context.Context, userID int64, fromMonth string, toMonth string) ([]domain.CashbackRow, error)
+	// GetMonths — несколько месяцев одним запросом; месяцы без данных в ответ не попадают
+	GetMonths(ctx context.Context, userID int64, months []string) ([]domain.CashbackMonth, error)
+	UsersWithMonth(ctx context.Context, monthTime string) ([]int64, error)
+	// GetMonthByCategory — разворот месяца «категория → банки»; пустой categoryName — все категории
+	GetMonthByCategory(ctx context.Context, userID int64, monthTime string, categoryName string) ([]domain.CategoryBanks, error)
+	// SearchHistory ищет по всем месяцам периода; результат от новых месяцев к старым
+	SearchHistory(ctx context.Context, userID int64, filter domain.HistoryFilter) ([]domain.CashbackRow, error)
+}
+
+// TxStorage выполняет несколько операций с кэшбэком атомарно
+type TxStorage interface {
+	// WithinTx выполняет fn в одной транзакции: ошибка fn откатывает всё, что сделано через tx
+	WithinTx(ctx context.Context, fn func(tx CashbackStorage) error) error
+}
+
+// OutboxStorage — события об изменениях месяцев, записанные в одной транзакции с
+// изменением. ClaimOutboxEvents блокирует неопубликованные события до lockedUntil
+// (как ClaimDueJobs) и отдаёт их по возрастанию ID.
+type OutboxStorage interface {
+	ClaimOutboxEvents(ctx context.Context, now, lockedUntil time.Time, limit int) ([]domain.Event, error)
+	MarkOutboxPublished(ctx context.Context, ids []int64) error
+	PurgeOutboxEvents(ctx context.Context, olderThan time.Time) (int64, error)
+}
+
+type SettingsStorage interface {
+	GetLanguage(ctx context.Context, userID int64) (string, error)
+	SetLanguage(ctx context.Context, userID int64, lang string) error
+}
+
+// UpdateStorage хранит update_id уже принятых обновлений Telegram,
+// чтобы повторные доставки webhook не обрабатывались дважды
+type UpdateStorage interface {
+	MarkUpdateProcessed(ctx context.Context, updateID int64) (bool, error)
+	PurgeProcessedUpdates(ctx context.Context, olderThan time.Time) (int64, error)
+}
+
+// IdempotencyStorage хранит первые ответы на запросы с Idempotency-Key
+type IdempotencyStorage interface {
+	// ReserveIdempotencyKey занимает ключ под запрос rec. Если ключ уже занят запросом
+	// новее expiredBefore, возвращает ту запись и ничего не меняет; более старая запись заменяется.
+	ReserveIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord, expiredBefore time.Time) (*domain.IdempotencyRecord, error)
+	// CompleteIdempotencyKey сохраняет ответ на запрос
+	CompleteIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord) error
+	// ReleaseIdempotencyKey освобождает ключ, если ответ сохранять не нужно (ошибка сервера)
+	ReleaseIdempotencyKey(ctx context.Context, userID int64, key, route string) error
+	PurgeIdempotencyKeys(ctx context.Context, olderThan time.Time) (int64, error)
+}
+
+// WebhookStorage хранит webhooks пользователей и очередь их доставок.
+// Методы с userID и id возвращают ErrNotFound, если webhook не принадлежит пользователю.
+type WebhookStorage interface {
+	// CreateWebhook возвращает ErrLimitExceeded, если у пользователя уже limit webhooks;
+	// проверка и вставка атомарны, параллельные запросы лимит не превысят
+	CreateWebhook(ctx context.Context, hook domain.Webhook, limit int) (*domain.Webhook, error)
+	ListWebhooks(ctx context.Context, userID int64) ([]domain.Webhook, error)
+	GetWebhook(ctx context.Context, userID, id int64) (*domain.Webhook, error)
+	// UpdateWebhook меняет адрес, фильтр событий и включённость; секрет не меняется
+	UpdateWebhook(ctx context.Context, hook domain.Webhook) error
+	DeleteWebhook(ctx context.Context, userID, id int64) error
+	// EnqueueDeliveries ставит событие в очередь каждому включённому webhook пользователя,
+	// подписанному на его вид; возвращает число поставленных доставок. Уже поставленное
+	// тому же webhook событие (тот же ID) повторно не ставится.
+	EnqueueDeliveries(ctx context.Context, e domain.Event, payload []byte) (int64, error)
+	// ClaimDueDeliveries блокирует созревшие доставки до lockedUntil, как ClaimDueJobs
+	ClaimDueDeliveries(ctx context.Context, now, lockedUntil time.Time, limit int) ([]domain.WebhookDelivery, error)
+	// RecordDeliveryAttempt сохраняет итог попытки и снимает блокировку
+	RecordDeliveryAttempt(ctx context.Context, d domain.WebhookDelivery) error
+	// ListDeliveries — журнал доставок webhook, новые первыми
+	ListDeliveries(ctx context.Context, userID, webhookID int64, limit int) ([]domain.WebhookDelivery, error)
+	PurgeDeliveries(ctx context.Context, olderThan time.Time) (int64, error)
+}
+
+// DeadLetterStorage сохраняет исходящие сообщения, которые так и не удалось отправить
+type DeadLetterStorage interface {
+	SaveDeadLetter(ctx context.Context, letter domain.DeadLetter) error
+}
+
+type ReminderStorage interface {
+	GetReminder(ctx context.Context, userID int64) (*domain.ReminderSettings, error)
+	SaveReminder(ctx context.Context, settings domain.ReminderSettings) error
+	DeleteReminder(ctx context.Context, userID int64) error
+}
+
+// JobStorage — очередь задач планировщика. ClaimDueJobs блокирует задачи до lockedUntil,
+// поэтому несколько процессов не выполнят одну задачу одновременно.
+type JobStorage interface {
+	ScheduleJob(ctx context.Context, kind string, userID int64, runAt time.Time) error
+	EnsureJob(ctx context.Context, kind string, userID int64, runAt time.Time) error
+	CancelJob(ctx context.Context, kind string, userID int64) error
+	ClaimDueJobs(ctx context.Context, now time.Time, lockedUntil time.Time, limit int) ([]domain.Job, error)
+	RescheduleJob(ctx context.Context, id int64, runAt time.Time, attempts int, lastError string) error
+	DeleteJob(ctx context.Context, id int64) error
+}
+
+type DeadlineStorage interface {
+	// правила сроков — свои у каждого пользователя
+	SetDeadlineRule(ctx context.Context, userID int64, rule domain.DeadlineRule) error
+	DeleteDeadlineRule(ctx context.Context, userID int64, bankName string) error
+	ListDeadlineRules(ctx context.Context, userID int64) ([]domain.DeadlineRule, error)
+	UsersWithDeadlineRules(ctx context.Context) ([]int64, error)
+	MarkDeadlineAlerted(ctx context.Context, userID int64, bankName string, monthStr string) (bool, error)
+}
+
+type PurchaseStorage interface {
+	AddPurchase(ctx context.Context, userID int64, purchase domain.Purchase) error
+	MonthPurchases(ctx context.Context, userID int64, monthTime string) ([]domain.PurchaseTotal, error)
+}
+
+// NameSearchStorage подбирает похожие названия среди банков и категорий пользователя
+type NameSearchStorage interface {
+	SuggestNames(ctx context.Context, userID int64, kind domain.NameKind, query string, limit int) ([]string, error)
 }
@@ -0,0 +1,18 @@
+// internal/storage/version.go
+package storage
+
+import "context"
+
+type versionKey struct{}
+
+// WithVersion требует, чтобы запись месяца прошла, только если его текущая версия
+// равна version (0 — месяца ещё нет). Иначе методы записи вернут ErrStaleVersion.
+func WithVersion(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// ExpectedVersion — версия, заданная через WithVersion; false, если проверять не нужно
+func ExpectedVersion(ctx context.Context) (int, bool) {
+	version, ok := ctx.Value(versionKey{}).(int)
+	return version, ok
+}
@@ -0,0 +1,54 @@
+// internal/summary/overview.go
+package summary
+
+import (
+	"cashback-tracker/internal/domain"
+	"fmt"
+	"sort"
+)
+
+// YearOverview — матрица «месяц × банк × категория»: строка на пару банк/категория,
+// в Percents — процент по месяцам (месяцы без этой пары отсутствуют)
+type YearOverview struct {
+	Year   int           `json:"year"`
+	Months []string      `json:"months"`
+	Rows   []OverviewRow `json:"rows"`
+}
+
+type OverviewRow struct {
+	Bank     string             `json:"bank"`
+	Category string             `json:"category"`
+	Percents map[string]float32 `json:"percents"`
+}
+
+// Overview собирает матрицу года из плоских строк GetRange
+func Overview(year int, rows []domain.CashbackRow) YearOverview {
+	overview := YearOverview{Year: year, Months: make([]string, 12), Rows: []OverviewRow{}}
+	for i := range overview.Months {
+		overview.Months[i] = fmt.Sprintf("%04d-%02d", year, i+1)
+	}
+
+	index := make(map[[2]string]int)
+	for _, r := range rows {
+		key := [2]string{r.Bank, r.Category}
+		i, ok := index[key]
+		if !ok {
+			i = len(overview.Rows)
+			index[key] = i
+			overview.Rows = append(overview.Rows, OverviewRow{
+				Bank:     r.Bank,
+				Category: r.Category,
+				Percents: make(map[string]float32),
+			})
+		}
+		overview.Rows[i].Percents[r.Month] = r.Percent
+	}
+
+	sort.Slice(overview.Rows, func(i, j int) bool {
+		if overview.Rows[i].Bank != overview.Rows[j].Bank {
+			return overview.Rows[i].Bank < overview.Rows[j].Bank
+		}
+		return overview.Rows[i].Category < overview.Rows[j].Category
+	})
+	return overview
+}
@@ -0,0 +1,192 @@
+// internal/summary/summary.go
+package summary
+
+import (
+	"cashback-tracker/internal/diff"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/fuzzy"
+	"cashback-tracker/internal/reminder"
+	"cashback-tracker/internal/storage"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// JobKind — ежемесячная системная задача рассылки итогов (user_id = 0)
+const JobKind = "month_digest"
+
+type Store interface {
+	GetMonths(ctx context.Context, userID int64, months []string) ([]domain.CashbackMonth, error)
+	storage.PurchaseStorage
+}
+
+// MonthSummary — итоги месяца. Earned, Spent, ByCategory и Unused заполняются,
+// только если за месяц записаны покупки (PurchasesLogged).
+type MonthSummary struct {
+	Month         string                      `json:"month"`
+	PreviousMonth string                      `json:"previous_month"`
+	Banks         []domain.BankWithCategories `json:"banks"`
+	BankCount     int                         `json:"bank_count"`
+	CategoryCount int                         `json:"category_count"`
+
+	PurchasesLogged bool                 `json:"purchases_logged"`
+	Spent           float64              `json:"spent,omitempty"`
+	Earned          float64              `json:"earned,omitempty"`
+	ByCategory      []CategoryResult     `json:"by_category,omitempty"`
+	Unused          []domain.CashbackRow `json:"unused,omitempty"`
+
+	Comparison Comparison `json:"comparison"`
+}
+
+// CategoryResult — сколько потрачено и получено по категории банка
+type CategoryResult struct {
+	Bank     string  `json:"bank"`
+	Category string  `json:"category"`
+	Percent  float32 `json:"percent"`
+	Spent    float64 `json:"spent"`
+	Earned   float64 `json:"earned"`
+}
+
+// Comparison — что изменилось относительно прошлого месяца
+type Comparison struct {
+	Added   []domain.CashbackRow `json:"added"`
+	Removed []domain.CashbackRow `json:"removed"`
+	Changed []PercentChange      `json:"changed"`
+}
+
+type PercentChange struct {
+	Bank       string  `json:"bank"`
+	Category   string  `json:"category"`
+	OldPercent float32 `json:"old_percent"`
+	NewPercent float32 `json:"new_percent"`
+}
+
+type Service struct {
+	store Store
+}
+
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Build собирает итоги месяца: оба месяца для сравнения берутся одним запросом
+func (s *Service) Build(ctx context.Context, userID int64, month string) (*MonthSummary, error) {
+	monthTime, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, fmt.Errorf("invalid month format: %w", err)
+	}
+	prev := monthTime.AddDate(0, -1, 0).Format("2006-01")
+
+	months, err := s.store.GetMonths(ctx, userID, []string{prev, month})
+	if err != nil {
+		return nil, err
+	}
+	var current, previous []domain.BankWithCategories
+	for _, m := range months {
+		switch m.Month {
+		case month:
+			current = m.Banks
+		case prev:
+			previous = m.Banks
+		}
+	}
+
+	result := &MonthSummary{
+		Month:         month,
+		PreviousMonth: prev,
+		Banks:         current,
+		BankCount:     len(current),
+		Comparison:    compare(prev, month, diff.Changes(previous, current, diff.Replace)),
+	}
+	if result.Banks == nil {
+		result.Banks = []domain.BankWithCategories{}
+	}
+	for _, bwc := range current {
+		result.CategoryCount += len(bwc.Categories)
+	}
+
+	purchases, err := s.store.MonthPurchases(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	if len(purchases) > 0 {
+		applyPurchases(result, current, purchases)
+	}
+	return result, nil
+}
+
+// applyPurchases считает кэшбэк по записанным покупкам. Покупка в категории,
+// которой нет в кэшбэке банка на этот месяц, учитывается в Spent, но ничего не приносит.
+func applyPurchases(result *MonthSummary, banks []domain.BankWithCategories, purchases []domain.PurchaseTotal) {
+	result.PurchasesLogged = true
+
+	// названия сравниваются без учёта регистра и лишних пробелов: «сбер» и «Сбер» —
+	// один банк, даже если покупку записали в другом написании
+	key := func(bank, category string) [2]string {
+		return [2]string{fuzzy.Normalize(bank), fuzzy.Normalize(category)}
+	}
+	spent := make(map[[2]string]float64, len(purchases))
+	for _, p := range purchases {
+		spent[key(p.Bank, p.Category)] += p.Amount
+		result.Spent += p.Amount
+	}
+
+	for _, bwc := range banks {
+		for _, cc := range bwc.Categories {
+			amount, ok := spent[key(bwc.Bank.Name, cc.Category.Name)]
+			if !ok {
+				result.Unused = append(result.Unused, domain.CashbackRow{
+					Month: result.Month, Bank: bwc.Bank.Name, Category: cc.Category.Name, Percent: cc.Percent,
+				})
+				continue
+			}
+			earned := round2(amount * float64(cc.Percent) / 100)
+			result.Earned += earned
+			result.ByCategory = append(result.ByCategory, CategoryResult{
+				Bank: bwc.Bank.Name, Category: cc.Category.Name, Percent: cc.Percent, Spent: amount, Earned: earned,
+			})
+		}
+	}
+	result.Spent = round2(result.Spent)
+	result.Earned = round2(result.Earned)
+	sort.SliceStable(result.ByCategory, func(i, j int) bool { return result.ByCategory[i].Earned > result.ByCategory[j].Earned })
+}
+
+// compare раскладывает изменения к прошлому месяцу по видам
+func compare(prevMonth, month string, changes []diff.Change) Comparison {
+	cmp := Comparison{Added: []domain.CashbackRow{}, Removed: []domain.CashbackRow{}, Changed: []PercentChange{}}
+	for _, ch := range changes {
+		switch ch.Action {
+		case diff.ActionAdd:
+			cmp.Added = append(cmp.Added, domain.CashbackRow{Month: month, Bank: ch.Bank, Category: ch.Category, Percent: *ch.NewPercent})
+		case diff.ActionRemove:
+			cmp.Removed = append(cmp.Removed, domain.CashbackRow{Month: prevMonth, Bank: ch.Bank, Category: ch.Category, Percent: *ch.OldPercent})
+		case diff.ActionUpdate:
+			cmp.Changed = append(cmp.Changed, PercentChange{Bank: ch.Bank, Category: ch.Category, OldPercent: *ch.OldPercent, NewPercent: *ch.NewPercent})
+		}
+	}
+	return cmp
+}
+
+// Location — часовой пояс рассылки итогов: по нему считаются и месяц, и время
+// запуска, где бы задачу ни завели
+func Location() *time.Location {
+	loc, err := time.LoadLocation(reminder.DefaultTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// NextRunAt — следующая рассылка итогов: 1-е число следующего месяца, 10:00 по Location
+func NextRunAt(now time.Time) time.Time {
+	loc := Location()
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month()+1, 1, 10, 0, 0, 0, loc)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -2,7 +2,9 @@
 package validator
 
 import (
+	"reflect"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -13,6 +15,18 @@ var Validate *validator.Validate
 func init() {
 	Validate = validator.New()
 
+	// В ошибках используем имена полей из JSON: клиент видит banks[0].percent, а не Banks[0].Percent
+	Validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
 	// Регистрируем кастомную валидацию для месяца: "2024-12"
 	_ = Validate.RegisterValidation("yearmonth", func(fl validator.FieldLevel) bool {
 		s := fl.Field().String()
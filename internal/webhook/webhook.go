@@ -0,0 +1,289 @@
+// internal/webhook/webhook.go
+package webhook
+
+import (
+	"bytes"
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/storage"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Заголовки запроса к получателю
+const (
+	SignatureHeader = "X-Cashback-Signature" // t=<unix>,v1=<hex HMAC-SHA256>
+	EventHeader     = "X-Cashback-Event"
+	DeliveryHeader  = "X-Cashback-Delivery"
+)
+
+const (
+	// MaxPerUser — сколько webhooks может завести один пользователь
+	MaxPerUser = 10
+	// MaxAttempts — после стольких неудачных попыток доставка помечается failed
+	MaxAttempts = 8
+
+	requestTimeout = 10 * time.Second
+	batchSize      = 20
+	// доставки пачки отправляются по очереди: lease должен пережить их все,
+	// иначе хвост пачки заберёт следующий тик и отправит второй раз
+	lease = batchSize*requestTimeout + time.Minute
+
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = 6 * time.Hour
+
+	// в журнал попадает только начало ответа получателя
+	maxErrorBody = 512
+)
+
+var (
+	errInvalidURL     = errors.New("webhook url must be an absolute https url")
+	errNonPublicHost  = errors.New("webhook host must resolve to public addresses")
+	errBlockedAddress = errors.New("webhook address is not public")
+)
+
+// nonPublic — диапазоны, которых нет среди IsPrivate/IsLoopback/IsLinkLocal* у netip.Addr
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64: внутри может быть любой IPv4
+}
+
+// publicAddr — можно ли слать запросы на адрес: не loopback, не частная сеть,
+// не link-local (в том числе метаданные облака 169.254.169.254) и не 0.0.0.0
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateURL принимает только абсолютные https-адреса без логина и пароля, все
+// адреса хоста которых публичные. Это ранняя понятная ошибка для пользователя;
+// от смены DNS после проверки защищает dialControl у клиента Dispatcher.
+func ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return errInvalidURL
+	}
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return errNonPublicHost
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return errNonPublicHost
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return errNonPublicHost
+		}
+	}
+	return nil
+}
+
+// dialControl проверяет адрес уже после разрешения имени, прямо перед соединением:
+// так не пройдёт и имя, которое после ValidateURL стали разрешать во внутренний адрес
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBlockedAddress, address)
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errBlockedAddress, address)
+	}
+	return nil
+}
+
+// newClient — HTTP-клиент доставок: соединяется только с публичными адресами
+// и не следует за редиректами, иначе получатель перенаправил бы запрос во внутреннюю сеть
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: dialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// NewSecret — случайный ключ подписи webhook
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign — значение SignatureHeader. Получатель считает HMAC-SHA256 от "<t>.<тело>"
+// своим секретом, сравнивает с v1 и отбрасывает запросы со старым t.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher ставит события в очередь доставок и доставляет их с повторами.
+// Очередь хранится в БД: недоставленное переживает перезапуск, а несколько
+// процессов не отправят одну доставку одновременно (ClaimDueDeliveries).
+type Dispatcher struct {
+	store    storage.WebhookStorage
+	client   *http.Client
+	interval time.Duration
+	wake     chan struct{}
+}
+
+func NewDispatcher(store storage.WebhookStorage, interval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		store:    store,
+		client:   newClient(),
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// SetClient заменяет HTTP-клиент, например на httptest.Server.Client() для самоподписанного
+// сертификата. Проверки адресов из newClient у такого клиента нет.
+func (d *Dispatcher) SetClient(client *http.Client) {
+	d.client = client
+}
+
+// Enqueue ставит событие в очередь доставок webhooks пользователя. Подписчик outbox.Relay:
+// при ошибке событие придёт снова. Повтор того же события доставок не добавляет —
+// на каждый webhook одно событие ставится один раз.
+func (d *Dispatcher) Enqueue(ctx context.Context, e domain.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	n, err := d.store.EnqueueDeliveries(ctx, e, payload)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		// не ждём тика: обычно получатель узнаёт об изменении сразу
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Run работает до отмены ctx: отправляет созревшие доставки
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+		d.tick(ctx)
+	}
+}
+
+func (d *Dispatcher) tick(ctx context.Context) {
+	now := time.Now()
+	deliveries, err := d.store.ClaimDueDeliveries(ctx, now, now.Add(lease), batchSize)
+	if err != nil {
+		slog.Error("Не удалось получить доставки webhook", "error", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			// незавершённые доставки освободятся по истечении lease
+			return
+		}
+		d.deliver(ctx, delivery)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery domain.WebhookDelivery) {
+	status, err := d.send(ctx, delivery)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatus = status
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliveryDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= MaxAttempts:
+		delivery.Status = domain.DeliveryFailed
+		delivery.LastError = err.Error()
+		slog.Warn("Доставка webhook не удалась, попытки исчерпаны", "error", err, "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID)
+	default:
+		delay := min(baseRetryDelay<<(delivery.Attempts-1), maxRetryDelay)
+		next := now.Add(delay)
+		delivery.NextAttemptAt = &next
+		delivery.LastError = err.Error()
+		slog.Info("Доставка webhook не удалась, повторим", "error", err, "delivery_id", delivery.ID, "retry_in", delay)
+	}
+
+	if err := d.store.RecordDeliveryAttempt(ctx, delivery); err != nil {
+		slog.Error("Не удалось сохранить попытку доставки webhook", "error", err, "delivery_id", delivery.ID)
+	}
+}
+
+// send отправляет доставку; ошибка — любой ответ кроме 2xx или отсутствие ответа
+func (d *Dispatcher) send(ctx context.Context, delivery domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cashback-tracker-webhooks/1")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
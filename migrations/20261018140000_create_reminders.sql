@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reminder_settings (
+    user_id BIGINT PRIMARY KEY,
+    day SMALLINT NOT NULL CHECK (day BETWEEN 1 AND 31),
+    remind_time TEXT NOT NULL,
+    timezone TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE scheduled_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    user_id BIGINT NOT NULL,
+    run_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    UNIQUE (kind, user_id)
+);
+
+CREATE INDEX idx_scheduled_jobs_run_at ON scheduled_jobs (run_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scheduled_jobs;
+DROP TABLE IF EXISTS reminder_settings;
+-- +goose StatementEnd
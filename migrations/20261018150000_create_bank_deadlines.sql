@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Правило пользователя для банка: до какого числа (day) можно выбрать категории на месяц.
+-- month_offset = 0 — срок в том же месяце («до 5-го»), -1 — в предыдущем («до конца прошлого месяца»).
+CREATE TABLE bank_deadline_rules (
+    user_id BIGINT NOT NULL,
+    bank_id INTEGER NOT NULL REFERENCES banks(id) ON DELETE CASCADE,
+    day SMALLINT NOT NULL CHECK (day BETWEEN 1 AND 31),
+    month_offset SMALLINT NOT NULL DEFAULT 0 CHECK (month_offset BETWEEN -1 AND 0),
+    PRIMARY KEY (user_id, bank_id)
+);
+
+-- Какие предупреждения о сроке уже отправлены, чтобы не повторять их каждый день
+CREATE TABLE deadline_alerts (
+    user_id BIGINT NOT NULL,
+    bank_id INTEGER NOT NULL REFERENCES banks(id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, bank_id, month)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS deadline_alerts;
+DROP TABLE IF EXISTS bank_deadline_rules;
+-- +goose StatementEnd
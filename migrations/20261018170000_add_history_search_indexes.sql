@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Поиск по истории сравнивает названия без учёта регистра и идёт от банка/категории к месяцам
+CREATE INDEX IF NOT EXISTS idx_banks_lower_name ON banks (lower(name));
+CREATE INDEX IF NOT EXISTS idx_categories_lower_name ON categories (lower(name));
+CREATE INDEX IF NOT EXISTS idx_bcc_category_id ON bank_cashback_categories (category_id);
+CREATE INDEX IF NOT EXISTS idx_bcc_bank_id ON bank_cashback_categories (bank_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_bcc_bank_id;
+DROP INDEX IF EXISTS idx_bcc_category_id;
+DROP INDEX IF EXISTS idx_categories_lower_name;
+DROP INDEX IF EXISTS idx_banks_lower_name;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- pg_trgm может быть недоступен (нет прав на CREATE EXTENSION) — тогда приложение
+-- подбирает похожие названия на стороне Go, и миграция не должна падать
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'pg_trgm unavailable: %', SQLERRM;
+END
+$$;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+        CREATE INDEX IF NOT EXISTS idx_banks_name_trgm ON banks USING gin (lower(name) gin_trgm_ops);
+        CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories USING gin (lower(name) gin_trgm_ops);
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_categories_name_trgm;
+DROP INDEX IF EXISTS idx_banks_name_trgm;
+-- +goose StatementEnd
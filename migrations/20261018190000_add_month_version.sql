@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Версия месяца растёт при каждом изменении; по ней API выдаёт ETag и проверяет If-Match.
+-- Версии берутся из общей последовательности: после удаления и повторного создания
+-- месяца его версия не начинается заново, и старый If-Match не совпадёт
+CREATE SEQUENCE IF NOT EXISTS cashback_month_version_seq;
+ALTER TABLE cashback_months ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT nextval('cashback_month_version_seq');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE cashback_months DROP COLUMN IF EXISTS version;
+DROP SEQUENCE IF EXISTS cashback_month_version_seq;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- События об изменениях месяцев; пишутся в транзакции изменения, публикуются outbox.Relay.
+-- id служит и id события (Last-Event-ID, заголовки webhook), поэтому не переиспользуется.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    type TEXT NOT NULL,
+    month TEXT NOT NULL,
+    bank TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_events;
+-- +goose StatementEnd
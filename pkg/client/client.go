@@ -0,0 +1,307 @@
+// pkg/client/client.go
+
+// Package client — Go-клиент REST API cashback-tracker: вход, месяцы (API v2),
+// поиск, подсказка карты для покупки (Recommend) и выгрузка. Ответы — типы из internal/domain, ошибки API — *Error
+// с кодом из problem+json. Запросы повторяются с экспоненциальной задержкой;
+// изменяющие запросы уходят с Idempotency-Key, поэтому повтор не применит их дважды.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	defaultMaxAttempts = 4
+	defaultBaseDelay   = 200 * time.Millisecond
+	defaultMaxDelay    = 5 * time.Second
+
+	// тело ответа об ошибке длиннее этого не читаем
+	maxErrorBody = 64 << 10
+)
+
+// Client — клиент API. Безопасен для одновременного использования из нескольких горутин.
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+	lang    string
+	retry   RetryPolicy
+
+	mu    sync.RWMutex
+	token string
+}
+
+// RetryPolicy — сколько раз и с какой задержкой повторять запрос. Повторяются
+// сетевые ошибки, 429 и 502/503/504; задержка растёт вдвое от BaseDelay до MaxDelay
+// со случайным разбросом, Retry-After сервера важнее. MaxAttempts = 1 — без повторов.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Option настраивает Client в New
+type Option func(*Client)
+
+// WithHTTPClient заменяет HTTP-клиент (по умолчанию — с таймаутом 30 секунд)
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithToken задаёт готовый JWT вместо Login
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithLanguage задаёт Accept-Language: на этом языке придут Error.Detail и сообщения о полях
+func WithLanguage(lang string) Option {
+	return func(c *Client) { c.lang = lang }
+}
+
+// WithRetry заменяет политику повторов
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+// New создаёт клиент для сервера baseURL, например "https://cashback.example.com"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("client: invalid base url %q", baseURL)
+	}
+	c := &Client{
+		baseURL: u,
+		http:    &http.Client{Timeout: defaultTimeout},
+		retry: RetryPolicy{
+			MaxAttempts: defaultMaxAttempts,
+			BaseDelay:   defaultBaseDelay,
+			MaxDelay:    defaultMaxDelay,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+	return c, nil
+}
+
+// Token — текущий JWT (после Login или из WithToken)
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// Login получает токен пользователя и использует его в следующих запросах
+func (c *Client) Login(ctx context.Context, userID int64) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/login",
+		body:   map[string]int64{"user_id": userID},
+		public: true,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.token = resp.Token
+	c.mu.Unlock()
+	return resp.Token, nil
+}
+
+// request — один вызов API
+type request struct {
+	method string
+	// path — экранированный путь от корня сервера
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	// public — без заголовка Authorization
+	public bool
+}
+
+// response — заголовки ответа, например ETag
+type response struct {
+	header http.Header
+}
+
+// do выполняет запрос с повторами и разбирает JSON-ответ в out (nil — тело не нужно)
+func (c *Client) do(ctx context.Context, r request, out any) error {
+	_, err := c.doRaw(ctx, r, decodeInto(r, out))
+	return err
+}
+
+// decodeInto разбирает JSON-ответ на запрос r в out
+func decodeInto(r request, out any) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		if out == nil || resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("client: decode %s %s: %w", r.method, r.path, err)
+		}
+		return nil
+	}
+}
+
+// doRaw выполняет запрос с повторами; read получает успешный (2xx или 304) ответ
+func (c *Client) doRaw(ctx context.Context, r request, read func(*http.Response) error) (response, error) {
+	var body []byte
+	if r.body != nil {
+		var err error
+		if body, err = json.Marshal(r.body); err != nil {
+			return response{}, fmt.Errorf("client: encode %s %s: %w", r.method, r.path, err)
+		}
+	}
+
+	header := r.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	// один ключ на все попытки: если ответ потерялся, сервер вернёт сохранённый
+	if r.method != http.MethodGet && header.Get(idempotencyHeader) == "" {
+		header.Set(idempotencyHeader, newIdempotencyKey())
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, r, header, body)
+		if err == nil && resp.StatusCode < 400 {
+			defer resp.Body.Close()
+			return response{header: resp.Header}, read(resp)
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			err = readError(resp)
+			resp.Body.Close()
+		}
+		if ctx.Err() != nil {
+			return response{}, ctx.Err()
+		}
+		if attempt >= c.retry.MaxAttempts || !retryable(err) {
+			return response{}, err
+		}
+
+		delay := max(c.backoff(attempt), retryAfter)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return response{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, r request, header http.Header, body []byte) (*http.Response, error) {
+	// r.path уже экранирован (pathf), поэтому собираем строку, а не url.URL.Path
+	target := c.baseURL.String() + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	if c.lang != "" {
+		req.Header.Set("Accept-Language", c.lang)
+	}
+	if !r.public {
+		if token := c.Token(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	return c.http.Do(req)
+}
+
+// backoff — задержка перед попыткой attempt+1: половина фиксирована, половина случайна
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.retry.BaseDelay << (attempt - 1)
+	if d <= 0 || d > c.retry.MaxDelay {
+		d = c.retry.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + mathrand.N(d/2+1)
+}
+
+// retryable — стоит ли повторять: сетевые сбои и временная недоступность сервера
+func retryable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+const idempotencyHeader = "Idempotency-Key"
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// pathf подставляет в шаблон пути экранированные сегменты: имя банка может
+// содержать пробелы и другие недопустимые в пути символы
+func pathf(format string, segments ...string) string {
+	args := make([]any, len(segments))
+	for i, s := range segments {
+		args[i] = url.PathEscape(s)
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,318 @@
+// pkg/client/client_test.go
+package client_test
+
+import (
+	"bytes"
+	"cashback-tracker/internal/auth"
+	"cashback-tracker/internal/config"
+	"cashback-tracker/internal/events"
+	"cashback-tracker/internal/handler"
+	"cashback-tracker/internal/middleware"
+	"cashback-tracker/pkg/client"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const month = "2026-10"
+
+// newServer поднимает настоящий роутер API на хранилище в памяти. wrap, если задан,
+// оборачивает роутер — так тесты подменяют ответы сервера.
+func newServer(t *testing.T, wrap func(http.Handler) http.Handler) (*client.Client, *memoryStore, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := newMemoryStore()
+	router := gin.New()
+	router.Use(middleware.Language())
+	tokens := auth.NewTokenService(config.Config{JWTSecret: "test-secret", JWTExpiresIn: time.Hour})
+	handler.Register(router, store, tokens, events.NewBus(1), time.Hour)
+
+	var h http.Handler = router
+	if wrap != nil {
+		h = wrap(router)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	c, err := client.New(srv.URL, client.WithRetry(client.RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, store, srv.URL
+}
+
+func login(t *testing.T, c *client.Client) {
+	t.Helper()
+	if _, err := c.Login(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func bank(name string, categories ...client.CashbackCategory) client.BankWithCategories {
+	return client.BankWithCategories{Bank: client.Bank{Name: name}, Categories: categories}
+}
+
+func category(name string, percent float32) client.CashbackCategory {
+	return client.CashbackCategory{Category: client.Category{Name: name}, Percent: percent}
+}
+
+// seed сохраняет месяц с двумя банками, у которых есть общая категория
+func seed(t *testing.T, c *client.Client) *client.Month {
+	t.Helper()
+	m, err := c.PutMonth(context.Background(), month, []client.BankWithCategories{
+		bank("Альфа", category("Кафе", 5), category("Такси", 3)),
+		bank("Т-Банк", category("Кафе", 7), category("АЗС", 2)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestLogin(t *testing.T) {
+	c, _, url := newServer(t, nil)
+	ctx := context.Background()
+
+	if _, err := c.GetMonth(ctx, month); !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("before login: err = %v, want ErrUnauthorized", err)
+	}
+
+	token, err := c.Login(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == "" || c.Token() != token {
+		t.Fatalf("Login token %q, Token() %q", token, c.Token())
+	}
+
+	_, err = c.GetMonth(ctx, month)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrNotFound) || apiErr.Code != "month_not_found" {
+		t.Fatalf("after login: err = %v, want month_not_found", err)
+	}
+
+	other, err := client.New(url, client.WithToken("not-a-jwt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.GetMonth(ctx, month); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("bad token: err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestMonthCRUD(t *testing.T) {
+	c, _, _ := newServer(t, nil)
+	ctx := context.Background()
+	login(t, c)
+
+	created := seed(t, c)
+	if created.Version == 0 || len(created.Banks) != 2 {
+		t.Fatalf("PutMonth = %+v", created)
+	}
+	got, err := c.GetMonth(ctx, month)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, created) {
+		t.Errorf("GetMonth = %+v, want %+v", got, created)
+	}
+
+	patched, err := c.PatchMonth(ctx, month, []client.BankWithCategories{bank("Альфа", category("Аптеки", 4))}, client.IfVersion(created.Version))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Version <= created.Version {
+		t.Errorf("PatchMonth version %d, want > %d", patched.Version, created.Version)
+	}
+	// запись по устаревшей версии не проходит и ничего не меняет
+	_, err = c.PatchMonth(ctx, month, []client.BankWithCategories{bank("Альфа", category("Кино", 10))}, client.IfVersion(created.Version))
+	var apiErr *client.Error
+	if !errors.Is(err, client.ErrPreconditionFailed) || !errors.As(err, &apiErr) || apiErr.Status != http.StatusPreconditionFailed {
+		t.Fatalf("stale PatchMonth: err = %v, want 412", err)
+	}
+	if err := c.DeleteMonth(ctx, month, client.IfVersion(created.Version)); !errors.Is(err, client.ErrPreconditionFailed) {
+		t.Fatalf("stale DeleteMonth: err = %v, want 412", err)
+	}
+	if _, _, err := c.GetCategory(ctx, month, "Альфа", "Кино"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("stale PatchMonth was applied: err = %v", err)
+	}
+
+	b, version, err := c.GetBank(ctx, month, "Т-Банк")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != patched.Version || len(b.Categories) != 2 {
+		t.Errorf("GetBank = %+v, version %d, want version %d", b, version, patched.Version)
+	}
+
+	cat, version, err := c.PutCategory(ctx, month, "Т-Банк", "Кафе", 10, client.IfVersion(version))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cat.Percent != 10 || version <= patched.Version {
+		t.Errorf("PutCategory = %+v, version %d, want 10%% and version > %d", cat, version, patched.Version)
+	}
+	_, current, err := c.GetCategory(ctx, month, "Т-Банк", "Кафе")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != version {
+		t.Errorf("PutCategory version %d, GetCategory version %d", version, current)
+	}
+
+	if err := c.DeleteCategory(ctx, month, "Т-Банк", "АЗС", client.IfVersion(current)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.GetCategory(ctx, month, "Т-Банк", "АЗС"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("deleted category: err = %v, want ErrNotFound", err)
+	}
+
+	if _, err := c.PutMonth(ctx, month, []client.BankWithCategories{bank("Альфа", category("Кафе", 500))}); !errors.As(err, &apiErr) || !errors.Is(err, client.ErrInvalid) {
+		t.Fatalf("invalid percent: err = %v, want ErrInvalid", err)
+	}
+	if len(apiErr.Errors) == 0 || !strings.HasSuffix(apiErr.Errors[0].Field, "percent") {
+		t.Errorf("invalid percent: field errors %+v", apiErr.Errors)
+	}
+
+	if err := c.DeleteMonth(ctx, month); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetMonth(ctx, month); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("deleted month: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	c, _, _ := newServer(t, nil)
+	ctx := context.Background()
+	login(t, c)
+	seed(t, c)
+
+	banks, err := c.SearchByCategory(ctx, month, "каф")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []client.Bank{{Name: "Альфа"}, {Name: "Т-Банк"}}; !reflect.DeepEqual(banks, want) {
+		t.Errorf("SearchByCategory = %+v, want %+v", banks, want)
+	}
+
+	categories, err := c.SearchByBank(ctx, month, "Т-Банк")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []client.Category{{Name: "АЗС"}, {Name: "Кафе"}}; !reflect.DeepEqual(categories, want) {
+		t.Errorf("SearchByBank = %+v, want %+v", categories, want)
+	}
+
+	rows, err := c.SearchHistory(ctx, client.HistoryFilter{Category: "кафе", From: month, To: month, MinPercent: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []client.CashbackRow{{Month: month, Bank: "Т-Банк", Category: "Кафе", Percent: 7}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("SearchHistory = %+v, want %+v", rows, want)
+	}
+
+	best, err := c.Recommend(ctx, month, "кафе")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []client.BankPercent{{Bank: "Т-Банк", Percent: 7}, {Bank: "Альфа", Percent: 5}}; !reflect.DeepEqual(best, want) {
+		t.Errorf("Recommend = %+v, want %+v", best, want)
+	}
+	none, err := c.Recommend(ctx, month, "Ювелирные")
+	if err != nil || len(none) != 0 {
+		t.Errorf("Recommend for an unknown category = %+v, %v", none, err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	c, _, _ := newServer(t, nil)
+	ctx := context.Background()
+	login(t, c)
+	seed(t, c)
+
+	rows, err := c.ExportRows(ctx, month, month)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []client.CashbackRow{
+		{Month: month, Bank: "Альфа", Category: "Кафе", Percent: 5},
+		{Month: month, Bank: "Альфа", Category: "Такси", Percent: 3},
+		{Month: month, Bank: "Т-Банк", Category: "АЗС", Percent: 2},
+		{Month: month, Bank: "Т-Банк", Category: "Кафе", Percent: 7},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("ExportRows = %+v, want %+v", rows, want)
+	}
+
+	var csv bytes.Buffer
+	if err := c.Export(ctx, &csv, month, month, client.ExportCSV); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(csv.String()), "\n"); len(lines) != len(want)+1 || !strings.Contains(lines[1], "Альфа") {
+		t.Errorf("CSV export:\n%s", csv.String())
+	}
+
+	if err := c.Export(ctx, &csv, month, "2026-01", client.ExportCSV); !errors.Is(err, client.ErrInvalid) {
+		t.Errorf("to before from: err = %v, want ErrInvalid", err)
+	}
+}
+
+// Ответ на запись потерялся (503 после того, как сервер её применил): клиент
+// повторяет запрос с тем же Idempotency-Key и получает сохранённый ответ
+func TestRetryDoesNotApplyWriteTwice(t *testing.T) {
+	var failed atomic.Bool
+	c, store, _ := newServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut && failed.CompareAndSwap(false, true) {
+				next.ServeHTTP(httptest.NewRecorder(), r)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	login(t, c)
+
+	m := seed(t, c)
+	if !failed.Load() {
+		t.Fatal("the write was not interrupted")
+	}
+	if store.writeCount() != 1 {
+		t.Errorf("month written %d times, want 1", store.writeCount())
+	}
+	if len(m.Banks) != 2 || m.Version == 0 {
+		t.Errorf("replayed PutMonth = %+v", m)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	c, _, _ := newServer(t, func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		})
+	})
+
+	_, err := c.Login(context.Background(), 1)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadGateway {
+		t.Fatalf("err = %v, want 502", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server got %d requests, want 3", calls.Load())
+	}
+}
@@ -0,0 +1,83 @@
+// pkg/client/errors.go
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Ошибки для errors.Is: *Error совпадает с ними по HTTP-статусу
+var (
+	ErrInvalid            = errors.New("client: invalid request")           // 400
+	ErrUnauthorized       = errors.New("client: unauthorized")              // 401
+	ErrNotFound           = errors.New("client: not found")                 // 404
+	ErrConflict           = errors.New("client: conflict")                  // 409
+	ErrPreconditionFailed = errors.New("client: month version has changed") // 412
+)
+
+// Error — ответ API об ошибке (RFC 7807). Code — стабильный машиночитаемый код
+// ("month_not_found", "validation_failed", ...), на него и стоит завязываться;
+// Detail — текст на языке WithLanguage.
+type Error struct {
+	Status int          `json:"status"`
+	Code   string       `json:"code"`
+	Title  string       `json:"title"`
+	Detail string       `json:"detail"`
+	Errors []FieldError `json:"errors"`
+}
+
+// FieldError — неверное поле запроса; Field — путь в JSON, например banks[0].categories[1].percent
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("api error %d %s: %s", e.Status, e.Code, msg)
+	}
+	return fmt.Sprintf("api error %d: %s", e.Status, msg)
+}
+
+// Is сопоставляет ошибку с ErrNotFound и остальными по статусу
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalid:
+		return e.Status == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrConflict:
+		return e.Status == http.StatusConflict
+	case ErrPreconditionFailed:
+		return e.Status == http.StatusPreconditionFailed
+	}
+	return false
+}
+
+// readError разбирает ответ об ошибке. Тело не в формате problem+json (прокси,
+// балансировщик) не теряется: оно попадает в Detail.
+func readError(resp *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err != nil {
+		return fmt.Errorf("client: read error response %d: %w", resp.StatusCode, err)
+	}
+	apiErr := &Error{}
+	if json.Unmarshal(body, apiErr) != nil || apiErr.Code == "" {
+		apiErr = &Error{Title: http.StatusText(resp.StatusCode), Detail: string(body)}
+	}
+	apiErr.Status = resp.StatusCode
+	if apiErr.Title == "" {
+		apiErr.Title = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
@@ -0,0 +1,332 @@
+// pkg/client/memory_test.go
+package client_test
+
+import (
+	"cashback-tracker/internal/domain"
+	"cashback-tracker/internal/handler"
+	"cashback-tracker/internal/storage"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStore — хранилище в памяти для маршрутов, которыми пользуется клиент:
+// месяцы с версиями и If-Match, поиск, выгрузка и Idempotency-Key. Остальные
+// методы handler.Store тестам не нужны и паникуют на nil-интерфейсе.
+type memoryStore struct {
+	handler.Store
+
+	mu      sync.Mutex
+	months  map[monthKey]*domain.CashbackMonth
+	version int
+	keys    map[string]*domain.IdempotencyRecord
+	writes  int
+}
+
+type monthKey struct {
+	userID int64
+	month  string
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{months: map[monthKey]*domain.CashbackMonth{}, keys: map[string]*domain.IdempotencyRecord{}}
+}
+
+// writeCount — сколько записей месяцев дошло до хранилища
+func (s *memoryStore) writeCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writes
+}
+
+// modify проверяет версию из контекста, как lockMonth, и меняет месяц под новую версию;
+// возвращает месяц до и после, как запись в postgres
+func (s *memoryStore) modify(ctx context.Context, userID int64, month string, fn func(m *domain.CashbackMonth) error) (*domain.MonthWrite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := monthKey{userID, month}
+	current := s.months[key]
+	version := 0
+	if current != nil {
+		version = current.Version
+	}
+	if expected, ok := storage.ExpectedVersion(ctx); ok && expected != version {
+		return nil, fmt.Errorf("%w: month version is %d, expected %d", storage.ErrStaleVersion, version, expected)
+	}
+	m := &domain.CashbackMonth{Month: month, UserID: userID}
+	if current != nil {
+		m.Banks = cloneBanks(current.Banks)
+	}
+	if err := fn(m); err != nil {
+		return nil, err
+	}
+	s.version++
+	m.Version = s.version
+	s.months[key] = m
+	s.writes++
+	return &domain.MonthWrite{Before: cloneMonth(current), After: cloneMonth(m)}, nil
+}
+
+// cloneMonth — копия месяца в том виде, в каком его отдаёт GetMonth: банки и категории по имени
+func cloneMonth(m *domain.CashbackMonth) *domain.CashbackMonth {
+	if m == nil {
+		return nil
+	}
+	out := *m
+	out.Banks = cloneBanks(m.Banks)
+	sort.Slice(out.Banks, func(i, j int) bool { return out.Banks[i].Bank.Name < out.Banks[j].Bank.Name })
+	for _, b := range out.Banks {
+		sort.Slice(b.Categories, func(i, j int) bool { return b.Categories[i].Category.Name < b.Categories[j].Category.Name })
+	}
+	return &out
+}
+
+func cloneBanks(banks []domain.BankWithCategories) []domain.BankWithCategories {
+	out := make([]domain.BankWithCategories, len(banks))
+	for i, b := range banks {
+		out[i] = domain.BankWithCategories{Bank: b.Bank, Categories: append([]domain.CashbackCategory(nil), b.Categories...)}
+	}
+	return out
+}
+
+func bankIndex(m *domain.CashbackMonth, name string) int {
+	for i, b := range m.Banks {
+		if b.Bank.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *memoryStore) SaveMonth(ctx context.Context, userID int64, month string, banks []domain.BankWithCategories) (*domain.MonthWrite, error) {
+	return s.modify(ctx, userID, month, func(m *domain.CashbackMonth) error {
+		m.Banks = cloneBanks(banks)
+		return nil
+	})
+}
+
+func (s *memoryStore) PatchMonth(ctx context.Context, userID int64, month string, banks []domain.BankWithCategories) (*domain.MonthWrite, error) {
+	return s.modify(ctx, userID, month, func(m *domain.CashbackMonth) error {
+		for _, b := range banks {
+			i := bankIndex(m, b.Bank.Name)
+			if i < 0 {
+				m.Banks = append(m.Banks, domain.BankWithCategories{Bank: b.Bank})
+				i = len(m.Banks) - 1
+			}
+		next:
+			for _, cat := range b.Categories {
+				for j := range m.Banks[i].Categories {
+					if m.Banks[i].Categories[j].Category.Name == cat.Category.Name {
+						m.Banks[i].Categories[j].Percent = cat.Percent
+						continue next
+					}
+				}
+				m.Banks[i].Categories = append(m.Banks[i].Categories, cat)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *memoryStore) UpdateBankCategories(ctx context.Context, userID int64, month, bank string, categories []domain.CashbackCategory) (*domain.MonthWrite, error) {
+	return s.modify(ctx, userID, month, func(m *domain.CashbackMonth) error {
+		i := bankIndex(m, bank)
+		if i < 0 {
+			return fmt.Errorf("%w: bank %s", storage.ErrNotFound, bank)
+		}
+		m.Banks[i].Categories = append([]domain.CashbackCategory(nil), categories...)
+		return nil
+	})
+}
+
+func (s *memoryStore) DeleteBankFromMonth(ctx context.Context, userID int64, month, bank string) error {
+	_, err := s.modify(ctx, userID, month, func(m *domain.CashbackMonth) error {
+		i := bankIndex(m, bank)
+		if i < 0 {
+			return fmt.Errorf("%w: bank %s", storage.ErrNotFound, bank)
+		}
+		m.Banks = append(m.Banks[:i], m.Banks[i+1:]...)
+		return nil
+	})
+	return err
+}
+
+func (s *memoryStore) DeleteCategoryFromBank(ctx context.Context, userID int64, month, bank, category string) error {
+	_, err := s.modify(ctx, userID, month, func(m *domain.CashbackMonth) error {
+		i := bankIndex(m, bank)
+		if i < 0 {
+			return fmt.Errorf("%w: bank %s", storage.ErrNotFound, bank)
+		}
+		cats := m.Banks[i].Categories
+		for j := range cats {
+			if cats[j].Category.Name == category {
+				m.Banks[i].Categories = append(cats[:j], cats[j+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: category %s", storage.ErrNotFound, category)
+	})
+	return err
+}
+
+func (s *memoryStore) DeleteMonth(ctx context.Context, userID int64, month string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := monthKey{userID, month}
+	current := s.months[key]
+	if current == nil {
+		return fmt.Errorf("%w: month %s", storage.ErrNotFound, month)
+	}
+	if expected, ok := storage.ExpectedVersion(ctx); ok && expected != current.Version {
+		return fmt.Errorf("%w: month version is %d, expected %d", storage.ErrStaleVersion, current.Version, expected)
+	}
+	delete(s.months, key)
+	s.writes++
+	return nil
+}
+
+func (s *memoryStore) GetMonth(_ context.Context, userID int64, month string) (*domain.CashbackMonth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneMonth(s.months[monthKey{userID, month}]), nil
+}
+
+// rows — все строки пользователя в порядке GetRange
+func (s *memoryStore) rows(userID int64) []domain.CashbackRow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []domain.CashbackRow
+	for key, m := range s.months {
+		if key.userID != userID {
+			continue
+		}
+		for _, b := range m.Banks {
+			for _, cat := range b.Categories {
+				rows = append(rows, domain.CashbackRow{Month: m.Month, Bank: b.Bank.Name, Category: cat.Category.Name, Percent: cat.Percent})
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Month != b.Month {
+			return a.Month < b.Month
+		}
+		if a.Bank != b.Bank {
+			return a.Bank < b.Bank
+		}
+		return a.Category < b.Category
+	})
+	return rows
+}
+
+func (s *memoryStore) GetRange(_ context.Context, userID int64, from, to string) ([]domain.CashbackRow, error) {
+	var rows []domain.CashbackRow
+	for _, r := range s.rows(userID) {
+		if r.Month >= from && r.Month <= to {
+			rows = append(rows, r)
+		}
+	}
+	return rows, nil
+}
+
+func (s *memoryStore) SearchByCategory(_ context.Context, userID int64, month, category string) ([]domain.Bank, error) {
+	var banks []domain.Bank
+	seen := map[string]bool{}
+	for _, r := range s.rows(userID) {
+		if r.Month == month && !seen[r.Bank] && strings.Contains(strings.ToLower(r.Category), strings.ToLower(category)) {
+			seen[r.Bank] = true
+			banks = append(banks, domain.Bank{Name: r.Bank})
+		}
+	}
+	return banks, nil
+}
+
+func (s *memoryStore) SearchByBank(_ context.Context, userID int64, month, bank string) ([]domain.Category, error) {
+	var categories []domain.Category
+	for _, r := range s.rows(userID) {
+		if r.Month == month && strings.Contains(strings.ToLower(r.Bank), strings.ToLower(bank)) {
+			categories = append(categories, domain.Category{Name: r.Category})
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+func (s *memoryStore) GetMonthByCategory(_ context.Context, userID int64, month, category string) ([]domain.CategoryBanks, error) {
+	var rows []domain.CashbackRow
+	for _, r := range s.rows(userID) {
+		if r.Month == month && (category == "" || strings.EqualFold(r.Category, category)) {
+			rows = append(rows, r)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Category != rows[j].Category {
+			return rows[i].Category < rows[j].Category
+		}
+		return rows[i].Percent > rows[j].Percent
+	})
+	var result []domain.CategoryBanks
+	for _, r := range rows {
+		if len(result) == 0 || result[len(result)-1].Category != r.Category {
+			result = append(result, domain.CategoryBanks{Category: r.Category})
+		}
+		cb := &result[len(result)-1]
+		cb.Banks = append(cb.Banks, domain.BankPercent{Bank: r.Bank, Percent: r.Percent})
+	}
+	return result, nil
+}
+
+func (s *memoryStore) SearchHistory(_ context.Context, userID int64, filter domain.HistoryFilter) ([]domain.CashbackRow, error) {
+	var rows []domain.CashbackRow
+	for _, r := range s.rows(userID) {
+		if r.Month < filter.From || r.Month > filter.To || r.Percent < filter.MinPercent ||
+			(filter.Category != "" && !strings.EqualFold(r.Category, filter.Category)) ||
+			(filter.Bank != "" && !strings.EqualFold(r.Bank, filter.Bank)) {
+			continue
+		}
+		rows = append(rows, r)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Month != rows[j].Month {
+			return rows[i].Month > rows[j].Month
+		}
+		return rows[i].Percent > rows[j].Percent
+	})
+	return rows, nil
+}
+
+func idempotencyKey(userID int64, key, route string) string {
+	return fmt.Sprint(userID, " ", key, " ", route)
+}
+
+func (s *memoryStore) ReserveIdempotencyKey(_ context.Context, rec domain.IdempotencyRecord, expiredBefore time.Time) (*domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := idempotencyKey(rec.UserID, rec.Key, rec.Route)
+	if existing := s.keys[k]; existing != nil && existing.CreatedAt.After(expiredBefore) {
+		found := *existing
+		return &found, nil
+	}
+	rec.CreatedAt = time.Now()
+	s.keys[k] = &rec
+	return nil, nil
+}
+
+func (s *memoryStore) CompleteIdempotencyKey(_ context.Context, rec domain.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing := s.keys[idempotencyKey(rec.UserID, rec.Key, rec.Route)]; existing != nil {
+		existing.Status, existing.ContentType, existing.Body = rec.Status, rec.ContentType, rec.Body
+	}
+	return nil
+}
+
+func (s *memoryStore) ReleaseIdempotencyKey(_ context.Context, userID int64, key, route string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, idempotencyKey(userID, key, route))
+	return nil
+}
@@ -0,0 +1,209 @@
+// pkg/client/months.go
+package client
+
+import (
+	"cashback-tracker/internal/domain"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Типы ответов — из internal/domain; псевдонимы позволяют назвать их вне модуля
+type (
+	Month              = domain.CashbackMonth
+	BankWithCategories = domain.BankWithCategories
+	CashbackCategory   = domain.CashbackCategory
+	Bank               = domain.Bank
+	Category           = domain.Category
+	CashbackRow        = domain.CashbackRow
+	CategoryBanks      = domain.CategoryBanks
+	BankPercent        = domain.BankPercent
+)
+
+// WriteOption уточняет изменяющий запрос
+type WriteOption func(http.Header)
+
+// IfVersion применяет запись, только если месяц не менялся с версии version
+// (Month.Version или версия из ответа); иначе — ошибка ErrPreconditionFailed
+func IfVersion(version int) WriteOption {
+	return func(h http.Header) { h.Set("If-Match", `"`+strconv.Itoa(version)+`"`) }
+}
+
+// IdempotencyKey задаёт свой Idempotency-Key вместо случайного, например чтобы
+// повтор после перезапуска программы не применил запись дважды
+func IdempotencyKey(key string) WriteOption {
+	return func(h http.Header) { h.Set(idempotencyHeader, key) }
+}
+
+func writeHeader(opts []WriteOption) http.Header {
+	h := make(http.Header)
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// тела запросов API: имена без вложенных объектов, в отличие от ответов
+type bankBody struct {
+	Name       string         `json:"name"`
+	Categories []categoryBody `json:"categories"`
+}
+
+type categoryBody struct {
+	Name    string  `json:"name"`
+	Percent float32 `json:"percent"`
+}
+
+func banksBody(banks []domain.BankWithCategories) []bankBody {
+	body := make([]bankBody, len(banks))
+	for i, b := range banks {
+		body[i] = bankBody{Name: b.Bank.Name, Categories: categoriesBody(b.Categories)}
+	}
+	return body
+}
+
+func categoriesBody(categories []domain.CashbackCategory) []categoryBody {
+	body := make([]categoryBody, len(categories))
+	for i, cat := range categories {
+		body[i] = categoryBody{Name: cat.Category.Name, Percent: cat.Percent}
+	}
+	return body
+}
+
+// GetMonth возвращает месяц ("2025-03"); сохранённого месяца нет — ErrNotFound
+func (c *Client) GetMonth(ctx context.Context, month string) (*domain.CashbackMonth, error) {
+	var m domain.CashbackMonth
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/api/v2/months/%s", month)}, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// PutMonth создаёт месяц или заменяет его целиком: банки и категории не из banks удаляются
+func (c *Client) PutMonth(ctx context.Context, month string, banks []domain.BankWithCategories, opts ...WriteOption) (*domain.CashbackMonth, error) {
+	return c.writeMonth(ctx, http.MethodPut, month, banks, opts)
+}
+
+// PatchMonth добавляет и обновляет банки и категории существующего месяца, не трогая остальные
+func (c *Client) PatchMonth(ctx context.Context, month string, banks []domain.BankWithCategories, opts ...WriteOption) (*domain.CashbackMonth, error) {
+	return c.writeMonth(ctx, http.MethodPatch, month, banks, opts)
+}
+
+func (c *Client) writeMonth(ctx context.Context, method, month string, banks []domain.BankWithCategories, opts []WriteOption) (*domain.CashbackMonth, error) {
+	var m domain.CashbackMonth
+	err := c.do(ctx, request{
+		method: method,
+		path:   pathf("/api/v2/months/%s", month),
+		header: writeHeader(opts),
+		body:   map[string]any{"banks": banksBody(banks)},
+	}, &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// DeleteMonth удаляет месяц со всеми банками
+func (c *Client) DeleteMonth(ctx context.Context, month string, opts ...WriteOption) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/api/v2/months/%s", month),
+		header: writeHeader(opts),
+	}, nil)
+}
+
+// ListBanks возвращает банки месяца с категориями
+func (c *Client) ListBanks(ctx context.Context, month string) ([]domain.BankWithCategories, error) {
+	var banks []domain.BankWithCategories
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/api/v2/months/%s/banks", month)}, &banks); err != nil {
+		return nil, err
+	}
+	return banks, nil
+}
+
+// GetBank возвращает банк месяца и версию месяца для IfVersion
+func (c *Client) GetBank(ctx context.Context, month, bank string) (*domain.BankWithCategories, int, error) {
+	var b domain.BankWithCategories
+	version, err := c.doVersioned(ctx, request{method: http.MethodGet, path: pathf("/api/v2/months/%s/banks/%s", month, bank)}, &b)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &b, version, nil
+}
+
+// PutBank создаёт банк в месяце или заменяет его категории; возвращает банк и новую версию месяца
+func (c *Client) PutBank(ctx context.Context, month, bank string, categories []domain.CashbackCategory, opts ...WriteOption) (*domain.BankWithCategories, int, error) {
+	var b domain.BankWithCategories
+	version, err := c.doVersioned(ctx, request{
+		method: http.MethodPut,
+		path:   pathf("/api/v2/months/%s/banks/%s", month, bank),
+		header: writeHeader(opts),
+		body:   map[string]any{"categories": categoriesBody(categories)},
+	}, &b)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &b, version, nil
+}
+
+// DeleteBank убирает банк из месяца
+func (c *Client) DeleteBank(ctx context.Context, month, bank string, opts ...WriteOption) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/api/v2/months/%s/banks/%s", month, bank),
+		header: writeHeader(opts),
+	}, nil)
+}
+
+// GetCategory возвращает категорию банка и версию месяца для IfVersion
+func (c *Client) GetCategory(ctx context.Context, month, bank, category string) (*domain.CashbackCategory, int, error) {
+	var cat domain.CashbackCategory
+	version, err := c.doVersioned(ctx, request{
+		method: http.MethodGet,
+		path:   pathf("/api/v2/months/%s/banks/%s/categories/%s", month, bank, category),
+	}, &cat)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &cat, version, nil
+}
+
+// PutCategory создаёт категорию в банке или меняет её процент; возвращает категорию и новую версию месяца
+func (c *Client) PutCategory(ctx context.Context, month, bank, category string, percent float32, opts ...WriteOption) (*domain.CashbackCategory, int, error) {
+	var cat domain.CashbackCategory
+	version, err := c.doVersioned(ctx, request{
+		method: http.MethodPut,
+		path:   pathf("/api/v2/months/%s/banks/%s/categories/%s", month, bank, category),
+		header: writeHeader(opts),
+		body:   map[string]float32{"percent": percent},
+	}, &cat)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &cat, version, nil
+}
+
+// DeleteCategory убирает категорию из банка
+func (c *Client) DeleteCategory(ctx context.Context, month, bank, category string, opts ...WriteOption) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   pathf("/api/v2/months/%s/banks/%s/categories/%s", month, bank, category),
+		header: writeHeader(opts),
+	}, nil)
+}
+
+// doVersioned — do, который дополнительно возвращает версию месяца из ETag
+func (c *Client) doVersioned(ctx context.Context, r request, out any) (int, error) {
+	resp, err := c.doRaw(ctx, r, decodeInto(r, out))
+	if err != nil {
+		return 0, err
+	}
+	return parseETag(resp.header.Get("ETag")), nil
+}
+
+// parseETag достаёт версию из ETag вида "3" или W/"3"; без заголовка — 0
+func parseETag(etag string) int {
+	v, _ := strconv.Atoi(strings.Trim(strings.TrimPrefix(etag, "W/"), `"`))
+	return v
+}
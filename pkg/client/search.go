@@ -0,0 +1,167 @@
+// pkg/client/search.go
+package client
+
+import (
+	"cashback-tracker/internal/domain"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// SearchByCategory — банки, у которых в месяце есть категория category: так
+// выбирают, какой картой платить
+func (c *Client) SearchByCategory(ctx context.Context, month, category string) ([]domain.Bank, error) {
+	var banks []domain.Bank
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/search/category",
+		query:  url.Values{"month": {month}, "q": {category}},
+	}, &banks)
+	if err != nil {
+		return nil, err
+	}
+	return banks, nil
+}
+
+// SearchByBank — категории банка bank в месяце
+func (c *Client) SearchByBank(ctx context.Context, month, bank string) ([]domain.Category, error) {
+	var categories []domain.Category
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/search/bank",
+		query:  url.Values{"month": {month}, "q": {bank}},
+	}, &categories)
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// MonthByCategory — разворот месяца «категория → банки»: по каждой категории банки
+// от большего процента к меньшему
+func (c *Client) MonthByCategory(ctx context.Context, month string) ([]domain.CategoryBanks, error) {
+	return c.monthByCategory(ctx, month, "")
+}
+
+// Recommend подсказывает, какой картой платить за покупку в категории category:
+// банки с кэшбэком на неё в месяце month, лучший первым. Имя категории сравнивается
+// без учёта регистра; пустой ответ — ни один банк по ней не платит.
+func (c *Client) Recommend(ctx context.Context, month, category string) ([]domain.BankPercent, error) {
+	pivot, err := c.monthByCategory(ctx, month, category)
+	if err != nil {
+		return nil, err
+	}
+	banks := []domain.BankPercent{}
+	for _, cb := range pivot {
+		banks = append(banks, cb.Banks...)
+	}
+	// имена, различающиеся только регистром, приходят отдельными категориями
+	slices.SortStableFunc(banks, func(a, b domain.BankPercent) int {
+		switch {
+		case a.Percent > b.Percent:
+			return -1
+		case a.Percent < b.Percent:
+			return 1
+		}
+		return 0
+	})
+	return banks, nil
+}
+
+func (c *Client) monthByCategory(ctx context.Context, month, category string) ([]domain.CategoryBanks, error) {
+	query := url.Values{"month": {month}}
+	if category != "" {
+		query.Set("category", category)
+	}
+	var pivot []domain.CategoryBanks
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/month/by-category", query: query}, &pivot); err != nil {
+		return nil, err
+	}
+	return pivot, nil
+}
+
+// HistoryFilter — условия SearchHistory; пустые поля не ограничивают поиск.
+// Без From и To сервер ищет за 12 месяцев до текущего включительно.
+type HistoryFilter = domain.HistoryFilter
+
+// SearchHistory — кешбэк за несколько месяцев, новые месяцы первыми
+func (c *Client) SearchHistory(ctx context.Context, filter HistoryFilter) ([]domain.CashbackRow, error) {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("category", filter.Category)
+	set("bank", filter.Bank)
+	set("from", filter.From)
+	set("to", filter.To)
+	if filter.MinPercent > 0 {
+		query.Set("min_percent", strconv.FormatFloat(float64(filter.MinPercent), 'f', -1, 32))
+	}
+
+	var rows []domain.CashbackRow
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/search/history", query: query}, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Autocomplete подсказывает имена банков (domain.NameBank) или категорий
+// (domain.NameCategory) по набранному тексту, лучшие первыми; limit <= 0 — по умолчанию сервера
+func (c *Client) Autocomplete(ctx context.Context, kind domain.NameKind, text string, limit int) ([]string, error) {
+	query := url.Values{"type": {string(kind)}, "q": {text}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var names []string
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/autocomplete", query: query}, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// ExportFormat — формат выгрузки: "csv", "xlsx" или "json"
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportXLSX ExportFormat = "xlsx"
+	ExportJSON ExportFormat = "json"
+)
+
+// Export пишет в w файл выгрузки за месяцы from..to включительно
+func (c *Client) Export(ctx context.Context, w io.Writer, from, to string, format ExportFormat) error {
+	header := make(http.Header)
+	header.Set("Accept", "*/*")
+	_, err := c.doRaw(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/export",
+		query:  url.Values{"from": {from}, "to": {to}, "format": {string(format)}},
+		header: header,
+	}, func(resp *http.Response) error {
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			return fmt.Errorf("client: read export: %w", err)
+		}
+		return nil
+	})
+	return err
+}
+
+// ExportRows — выгрузка за месяцы from..to в виде строк
+func (c *Client) ExportRows(ctx context.Context, from, to string) ([]domain.CashbackRow, error) {
+	var rows []domain.CashbackRow
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/export",
+		query:  url.Values{"from": {from}, "to": {to}, "format": {string(ExportJSON)}},
+	}, &rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}